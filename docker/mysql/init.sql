@@ -43,13 +43,23 @@ CREATE TABLE IF NOT EXISTS order_products (
 CREATE TABLE IF NOT EXISTS outbox (
     id BIGINT AUTO_INCREMENT PRIMARY KEY,
     aggregate VARCHAR(50) NOT NULL,
+    aggregate_id VARCHAR(100) NOT NULL DEFAULT '',
     event_type VARCHAR(100) NOT NULL,
     payload JSON NOT NULL,
     headers JSON,
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     processed_at TIMESTAMP NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'PENDING',
+    attempts INT NOT NULL DEFAULT 0,
+    last_error TEXT NULL,
+    next_attempt_at TIMESTAMP NULL,
+    claimed_by VARCHAR(100) NULL,
+    claimed_until TIMESTAMP NULL,
     INDEX idx_processed_at (processed_at),
-    INDEX idx_aggregate_event (aggregate, event_type)
+    INDEX idx_processed_claimed (processed_at, claimed_until),
+    INDEX idx_status_next_attempt (status, next_attempt_at),
+    INDEX idx_aggregate_event (aggregate, event_type),
+    INDEX idx_aggregate_key (aggregate, aggregate_id, processed_at)
 );
 
 -- Tabela para controle de idempotência (usada pelos serviços de leitura)
@@ -0,0 +1,55 @@
+-- Atualiza a tabela outbox de bancos criados antes das colunas de reserva (lease), tentativas e
+-- ordem por agregado. O init.sql só é executado em um volume vazio; bancos existentes recebem
+-- estas colunas com `make migrate-db`. O script é idempotente: colunas e índices já existentes
+-- são mantidos.
+
+DROP PROCEDURE IF EXISTS outbox_add_column;
+DROP PROCEDURE IF EXISTS outbox_add_index;
+
+DELIMITER //
+
+CREATE PROCEDURE outbox_add_column(IN new_column VARCHAR(64), IN new_definition VARCHAR(255))
+BEGIN
+    IF NOT EXISTS (
+        SELECT 1 FROM information_schema.columns
+        WHERE table_schema = DATABASE() AND table_name = 'outbox' AND column_name = new_column
+    ) THEN
+        SET @ddl = CONCAT('ALTER TABLE outbox ADD COLUMN ', new_column, ' ', new_definition);
+        PREPARE ddl_statement FROM @ddl;
+        EXECUTE ddl_statement;
+        DEALLOCATE PREPARE ddl_statement;
+    END IF;
+END //
+
+CREATE PROCEDURE outbox_add_index(IN new_index VARCHAR(64), IN new_columns VARCHAR(255))
+BEGIN
+    IF NOT EXISTS (
+        SELECT 1 FROM information_schema.statistics
+        WHERE table_schema = DATABASE() AND table_name = 'outbox' AND index_name = new_index
+    ) THEN
+        SET @ddl = CONCAT('ALTER TABLE outbox ADD INDEX ', new_index, ' (', new_columns, ')');
+        PREPARE ddl_statement FROM @ddl;
+        EXECUTE ddl_statement;
+        DEALLOCATE PREPARE ddl_statement;
+    END IF;
+END //
+
+DELIMITER ;
+
+CALL outbox_add_column('aggregate_id', 'VARCHAR(100) NOT NULL DEFAULT '''' AFTER aggregate');
+CALL outbox_add_column('status', 'VARCHAR(20) NOT NULL DEFAULT ''PENDING''');
+CALL outbox_add_column('attempts', 'INT NOT NULL DEFAULT 0');
+CALL outbox_add_column('last_error', 'TEXT NULL');
+CALL outbox_add_column('next_attempt_at', 'TIMESTAMP NULL');
+CALL outbox_add_column('claimed_by', 'VARCHAR(100) NULL');
+CALL outbox_add_column('claimed_until', 'TIMESTAMP NULL');
+
+-- Mensagens já publicadas antes da migração não voltam a ser pendentes
+UPDATE outbox SET status = 'PROCESSED' WHERE processed_at IS NOT NULL AND status = 'PENDING';
+
+CALL outbox_add_index('idx_processed_claimed', 'processed_at, claimed_until');
+CALL outbox_add_index('idx_status_next_attempt', 'status, next_attempt_at');
+CALL outbox_add_index('idx_aggregate_key', 'aggregate, aggregate_id, processed_at');
+
+DROP PROCEDURE outbox_add_column;
+DROP PROCEDURE outbox_add_index;
@@ -0,0 +1,97 @@
+package broker
+
+import (
+	"fmt"
+	"time"
+	"pkg/broker/jetstream"
+	"pkg/broker/memory"
+	pkgconfig "pkg/config"
+	pkgkafka "pkg/kafka"
+)
+
+// Backends disponíveis em BROKER_BACKEND
+const (
+	BackendKafka  = "kafka"
+	BackendNATS   = "nats"
+	BackendMemory = "memory"
+)
+
+// Backend transporte usado pelo Producer e pelo Router, com o fechamento das suas conexões
+type Backend interface {
+	pkgkafka.Broker
+	Close() error
+}
+
+// New cria o transporte selecionado em BROKER_BACKEND. Os handlers não mudam: Producer e Router
+// funcionam da mesma forma, inclusive retry e DLQ, sobre qualquer backend.
+func New(config *pkgconfig.Config) (Backend, error) {
+	switch config.BrokerBackend {
+	case BackendKafka, "":
+		return NewKafka(config)
+	case BackendNATS:
+		registry, err := TopicRegistry(config)
+		if err != nil {
+			return nil, err
+		}
+		broker, err := jetstream.NewBroker(jetstream.Config{
+			URL:           config.NATSURL,
+			Stream:        config.NATSStream,
+			SubjectPrefix: config.NATSSubjectPrefix,
+			Embedded:      config.NATSEmbedded,
+			StoreDir:      config.NATSStoreDir,
+			MaxAge:        streamMaxAge(registry),
+			MaxBytes:      config.NATSStreamMaxBytes,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return broker, nil
+	case BackendMemory:
+		// Só entrega mensagens dentro do mesmo processo (testes e desenvolvimento local)
+		return memory.NewBroker(3), nil
+	default:
+		return nil, fmt.Errorf("BROKER_BACKEND inválido: %q (use kafka, nats ou memory)", config.BrokerBackend)
+	}
+}
+
+// streamMaxAge retenção da stream do JetStream, que guarda todos os tópicos: a maior retenção do
+// registry (em geral a da DLQ), para que nenhum tópico perca mensagens antes do previsto. Tópicos
+// sem expiração (ou com a retenção padrão do broker) deixam a stream sem expiração.
+func streamMaxAge(registry *pkgkafka.TopicRegistry) time.Duration {
+	var maxAge time.Duration
+	for _, spec := range registry.Specs() {
+		if spec.Retention <= 0 {
+			return 0
+		}
+		if spec.Retention > maxAge {
+			maxAge = spec.Retention
+		}
+	}
+	return maxAge
+}
+
+// NewKafka cria o transporte Kafka com o Producer configurado em KAFKA_PRODUCER_*
+func NewKafka(config *pkgconfig.Config) (*pkgkafka.KafkaBroker, error) {
+	acks, err := pkgkafka.ParseRequiredAcks(config.KafkaProducerAcks)
+	if err != nil {
+		return nil, fmt.Errorf("KAFKA_PRODUCER_ACKS: %w", err)
+	}
+	compression, err := pkgkafka.ParseCompression(config.KafkaProducerCompression)
+	if err != nil {
+		return nil, fmt.Errorf("KAFKA_PRODUCER_COMPRESSION: %w", err)
+	}
+
+	producer := pkgkafka.DefaultProducerConfig()
+	producer.RequiredAcks = acks
+	producer.Compression = compression
+	producer.BatchTimeout = config.GetKafkaProducerBatchTimeout()
+	producer.WriteTimeout = config.GetKafkaProducerWriteTimeout()
+	if config.KafkaProducerBatchSize > 0 {
+		producer.BatchSize = config.KafkaProducerBatchSize
+	}
+	if config.KafkaProducerMaxAttempts > 0 {
+		producer.MaxAttempts = config.KafkaProducerMaxAttempts
+	}
+
+	return pkgkafka.NewKafkaBrokerWithConfig(config.GetKafkaBrokers(), producer), nil
+}
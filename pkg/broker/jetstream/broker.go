@@ -0,0 +1,204 @@
+package jetstream
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	pkgkafka "pkg/kafka"
+
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+	"github.com/rs/zerolog/log"
+)
+
+// Config configuração do transporte NATS JetStream
+type Config struct {
+	URL           string        // Servidor NATS (ignorado com Embedded)
+	Stream        string        // Stream que armazena todos os tópicos
+	SubjectPrefix string        // Prefixo dos subjects: tópico "order.created" -> "events.order.created"
+	AckWait       time.Duration // Tempo até uma mensagem entregue e não confirmada ser reenviada
+	Embedded      bool          // Inicia um nats-server com JetStream no próprio processo
+	StoreDir      string        // Diretório de armazenamento do servidor embutido
+	MaxAge        time.Duration // Retenção das mensagens da stream (0 = sem expiração)
+	MaxBytes      int64         // Tamanho máximo da stream; as mais antigas são descartadas (0 = sem limite)
+}
+
+// Broker implementa pkgkafka.Broker sobre NATS JetStream. Todos os tópicos (inclusive os de retry
+// e a DLQ, que para o Router são apenas tópicos) ficam em uma única stream, um subject por tópico.
+// Cada reader do Router é um consumer durável de pull, identificado pelo consumer group e pelos
+// tópicos lidos, então o progresso sobrevive a reinícios como os offsets do Kafka.
+type Broker struct {
+	config Config
+	server *server.Server // Servidor embutido, se houver
+	conn   *nats.Conn
+	js     nats.JetStreamContext
+}
+
+// NewBroker conecta ao NATS (ou inicia o servidor embutido) e garante a stream dos tópicos
+func NewBroker(config Config) (*Broker, error) {
+	if config.Stream == "" {
+		config.Stream = "EVENTS"
+	}
+	if config.SubjectPrefix == "" {
+		config.SubjectPrefix = "events"
+	}
+	if config.AckWait <= 0 {
+		config.AckWait = 5 * time.Minute
+	}
+
+	b := &Broker{config: config}
+
+	url := config.URL
+	if config.Embedded {
+		embedded, err := startEmbeddedServer(config.StoreDir)
+		if err != nil {
+			return nil, err
+		}
+		b.server = embedded
+		url = embedded.ClientURL()
+	}
+
+	conn, err := nats.Connect(url,
+		nats.Name("ecommerce"),
+		nats.MaxReconnects(-1),
+		nats.DisconnectErrHandler(func(_ *nats.Conn, err error) {
+			if err != nil {
+				log.Warn().Err(err).Msg("conexão com o NATS perdida")
+			}
+		}),
+		nats.ReconnectHandler(func(conn *nats.Conn) {
+			log.Info().Str("url", conn.ConnectedUrl()).Msg("reconectado ao NATS")
+		}),
+	)
+	if err != nil {
+		b.shutdownServer()
+		return nil, fmt.Errorf("erro ao conectar ao NATS: %w", err)
+	}
+	b.conn = conn
+
+	js, err := conn.JetStream()
+	if err != nil {
+		b.Close()
+		return nil, fmt.Errorf("erro ao acessar o JetStream: %w", err)
+	}
+	b.js = js
+
+	if err := b.ensureStream(); err != nil {
+		b.Close()
+		return nil, err
+	}
+
+	log.Info().
+		Str("url", url).
+		Str("stream", config.Stream).
+		Bool("embedded", config.Embedded).
+		Msg("conectado ao NATS JetStream")
+
+	return b, nil
+}
+
+// startEmbeddedServer inicia um nats-server com JetStream em uma porta livre
+func startEmbeddedServer(storeDir string) (*server.Server, error) {
+	embedded, err := server.NewServer(&server.Options{
+		Host:      "127.0.0.1",
+		Port:      server.RANDOM_PORT,
+		JetStream: true,
+		StoreDir:  storeDir,
+		NoSigs:    true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("erro ao criar servidor NATS embutido: %w", err)
+	}
+
+	go embedded.Start()
+	if !embedded.ReadyForConnections(10 * time.Second) {
+		embedded.Shutdown()
+		return nil, errors.New("servidor NATS embutido não ficou pronto")
+	}
+	return embedded, nil
+}
+
+// ensureStream cria a stream dos tópicos, se ainda não existir, e mantém a retenção e o tamanho
+// máximo de uma stream existente alinhados à configuração
+func (b *Broker) ensureStream() error {
+	maxBytes := b.config.MaxBytes
+	if maxBytes <= 0 {
+		maxBytes = -1 // Sem limite
+	}
+
+	info, err := b.js.StreamInfo(b.config.Stream)
+	switch {
+	case errors.Is(err, nats.ErrStreamNotFound):
+		_, err = b.js.AddStream(&nats.StreamConfig{
+			Name:      b.config.Stream,
+			Subjects:  []string{b.config.SubjectPrefix + ".>"},
+			Storage:   nats.FileStorage,
+			Retention: nats.LimitsPolicy,
+			MaxAge:    b.config.MaxAge,
+			MaxBytes:  maxBytes,
+		})
+		if err != nil {
+			return fmt.Errorf("erro ao criar a stream %s: %w", b.config.Stream, err)
+		}
+		return nil
+	case err != nil:
+		return fmt.Errorf("erro ao consultar a stream %s: %w", b.config.Stream, err)
+	}
+
+	if info.Config.MaxAge == b.config.MaxAge && info.Config.MaxBytes == maxBytes {
+		return nil
+	}
+
+	config := info.Config
+	config.MaxAge = b.config.MaxAge
+	config.MaxBytes = maxBytes
+	if _, err := b.js.UpdateStream(&config); err != nil {
+		return fmt.Errorf("erro ao atualizar os limites da stream %s: %w", b.config.Stream, err)
+	}
+	log.Info().
+		Str("stream", b.config.Stream).
+		Dur("max_age", config.MaxAge).
+		Int64("max_bytes", config.MaxBytes).
+		Msg("limites da stream atualizados")
+	return nil
+}
+
+// NewWriter cria um writer que publica na stream
+func (b *Broker) NewWriter() pkgkafka.Writer {
+	return &writer{broker: b}
+}
+
+// NewGroupReader cria um reader do consumer durável do grupo para os tópicos informados. O
+// consumer é criado (ou retomado) na primeira leitura; erros aparecem em FetchMessage.
+func (b *Broker) NewGroupReader(topics []string, groupID string) pkgkafka.Reader {
+	return newReader(b, topics, groupID)
+}
+
+// subject converte um tópico no subject da stream
+func (b *Broker) subject(topic string) string {
+	return b.config.SubjectPrefix + "." + topic
+}
+
+// topic converte um subject da stream de volta no tópico
+func (b *Broker) topic(subject string) string {
+	return strings.TrimPrefix(subject, b.config.SubjectPrefix+".")
+}
+
+// Close fecha a conexão e encerra o servidor embutido, se houver. Os readers devem ser fechados
+// antes (o Router os fecha ao final de Run).
+func (b *Broker) Close() error {
+	if b.conn != nil {
+		b.conn.Close()
+	}
+	b.shutdownServer()
+	return nil
+}
+
+// shutdownServer encerra o servidor embutido, se houver
+func (b *Broker) shutdownServer() {
+	if b.server != nil {
+		b.server.Shutdown()
+		b.server.WaitForShutdown()
+	}
+}
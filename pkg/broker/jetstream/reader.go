@@ -0,0 +1,387 @@
+package jetstream
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/rs/zerolog/log"
+	"github.com/segmentio/kafka-go"
+)
+
+// Limites da leitura dos consumers duráveis
+const (
+	fetchBatch    = 64              // Mensagens pedidas ao servidor por chamada
+	fetchWait     = 5 * time.Second // Espera máxima de cada pedido
+	maxAckPending = 1024            // Mensagens entregues e ainda não confirmadas por consumer
+	retryBackoff  = time.Second     // Espera após erro de conexão ou de criação do consumer
+)
+
+// invalidDurableChars caracteres não permitidos no nome de um consumer durável
+var invalidDurableChars = regexp.MustCompile(`[^A-Za-z0-9_-]`)
+
+// pendingMessage mensagem entregue ao Router e ainda não confirmada
+type pendingMessage struct {
+	sequence uint64
+	msg      *nats.Msg
+}
+
+// reader membro de um consumer group. Cada tópico é lido por um consumer durável de pull próprio
+// (<grupo>__<tópico>), então incluir um tópico no Router não reinicia o progresso dos demais. Cada
+// tópico se comporta como uma partição única (partição 0) cujo offset é a sequência na stream.
+//
+// Os pollers só buscam mensagens quando o Router pede (FetchMessage): enquanto o Router não lê,
+// nada novo é buscado. Enquanto uma mensagem está retida (aguardando a entrega, o atraso do nível
+// de retry ou o handler), o reader envia InProgress periodicamente para que o servidor não a
+// reentregue ao fim do AckWait.
+type reader struct {
+	broker  *Broker
+	groupID string
+	topics  []string
+
+	start    sync.Once
+	messages chan *nats.Msg  // Buscadas pelos pollers e ainda não entregues ao Router
+	demand   []chan struct{} // Um por poller: sinalizado a cada FetchMessage
+	errs     chan error
+	stop     context.CancelFunc
+	workers  sync.WaitGroup
+
+	mu       sync.Mutex
+	pending  map[string][]pendingMessage // Por tópico, em ordem de entrega
+	inFlight map[*nats.Msg]struct{}      // Buscadas e ainda não confirmadas nem devolvidas
+	closed   bool
+	done     chan struct{}
+}
+
+// newReader cria o reader do grupo; os consumers são criados na primeira leitura
+func newReader(b *Broker, topics []string, groupID string) *reader {
+	demand := make([]chan struct{}, len(topics))
+	for i := range demand {
+		demand[i] = make(chan struct{}, 1)
+	}
+	return &reader{
+		broker:   b,
+		groupID:  groupID,
+		topics:   topics,
+		messages: make(chan *nats.Msg, fetchBatch),
+		demand:   demand,
+		errs:     make(chan error, 1),
+		pending:  make(map[string][]pendingMessage),
+		inFlight: make(map[*nats.Msg]struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+// FetchMessage retorna a próxima mensagem dos tópicos do grupo, sem confirmá-la
+func (r *reader) FetchMessage(ctx context.Context) (kafka.Message, error) {
+	r.start.Do(r.startPollers)
+
+	// Pede aos pollers o próximo lote; sem pedidos do Router nada é buscado
+	for _, demand := range r.demand {
+		select {
+		case demand <- struct{}{}:
+		default:
+		}
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return kafka.Message{}, ctx.Err()
+		case <-r.done:
+			return kafka.Message{}, io.EOF
+		case err := <-r.errs:
+			return kafka.Message{}, err
+		case msg := <-r.messages:
+			message, sequence, err := r.toKafkaMessage(msg)
+			if err != nil {
+				// Sem metadados do JetStream não há como confirmar; a mensagem é descartada do consumer
+				log.Error().Err(err).Str("subject", msg.Subject).Msg("mensagem inválida recebida do JetStream")
+				r.release(msg)
+				msg.Term()
+				continue
+			}
+
+			r.mu.Lock()
+			if r.closed {
+				// Close já devolveu a mensagem ao servidor
+				r.mu.Unlock()
+				return kafka.Message{}, io.EOF
+			}
+			r.pending[message.Topic] = append(r.pending[message.Topic], pendingMessage{sequence: sequence, msg: msg})
+			r.mu.Unlock()
+			return message, nil
+		}
+	}
+}
+
+// startPollers inicia uma goroutine de leitura por tópico e a de heartbeat das mensagens retidas
+func (r *reader) startPollers() {
+	ctx, cancel := context.WithCancel(context.Background())
+	r.stop = cancel
+
+	for i, topic := range r.topics {
+		r.workers.Add(1)
+		go func(topic string, demand <-chan struct{}) {
+			defer r.workers.Done()
+			r.poll(ctx, topic, demand)
+		}(topic, r.demand[i])
+	}
+
+	r.workers.Add(1)
+	go func() {
+		defer r.workers.Done()
+		r.heartbeat(ctx)
+	}()
+}
+
+// poll busca as mensagens do consumer do tópico, em ordem, a cada pedido do Router, até o reader
+// ser fechado
+func (r *reader) poll(ctx context.Context, topic string, demand <-chan struct{}) {
+	var sub *nats.Subscription
+	defer func() {
+		if sub != nil {
+			sub.Unsubscribe()
+		}
+	}()
+
+	for ctx.Err() == nil {
+		select {
+		case <-ctx.Done():
+			return
+		case <-demand:
+		}
+
+		if sub == nil {
+			var err error
+			if sub, err = r.subscribe(topic); err != nil {
+				r.fail(ctx, err)
+				continue
+			}
+		}
+
+		fetchCtx, cancel := context.WithTimeout(ctx, fetchWait)
+		msgs, err := sub.Fetch(fetchBatch, nats.Context(fetchCtx))
+		cancel()
+		if err != nil {
+			if ctx.Err() != nil || errors.Is(err, context.DeadlineExceeded) || errors.Is(err, nats.ErrTimeout) {
+				continue
+			}
+			r.fail(ctx, fmt.Errorf("erro ao buscar mensagens do tópico %s: %w", topic, err))
+			continue
+		}
+
+		r.mu.Lock()
+		for _, msg := range msgs {
+			r.inFlight[msg] = struct{}{}
+		}
+		r.mu.Unlock()
+
+		for _, msg := range msgs {
+			select {
+			case r.messages <- msg:
+			case <-ctx.Done():
+				// Fechado antes da entrega: Close devolve as mensagens retidas para reentrega
+				return
+			}
+		}
+	}
+}
+
+// heartbeat envia InProgress para as mensagens retidas a cada terço do AckWait, adiando a
+// reentrega enquanto aguardam a entrega ao Router, o atraso do nível de retry ou o handler
+func (r *reader) heartbeat(ctx context.Context) {
+	ticker := time.NewTicker(r.broker.config.AckWait / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		r.mu.Lock()
+		held := make([]*nats.Msg, 0, len(r.inFlight))
+		for msg := range r.inFlight {
+			held = append(held, msg)
+		}
+		r.mu.Unlock()
+
+		for _, msg := range held {
+			if err := msg.InProgress(); err != nil && !errors.Is(err, nats.ErrMsgAlreadyAckd) {
+				log.Warn().Err(err).Str("subject", msg.Subject).Msg("erro ao estender o prazo de confirmação da mensagem")
+			}
+		}
+	}
+}
+
+// release deixa de acompanhar a mensagem retida (confirmada ou devolvida ao servidor)
+func (r *reader) release(msg *nats.Msg) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.inFlight, msg)
+}
+
+// fail repassa o erro ao Router (que o registra) e aguarda antes de tentar novamente
+func (r *reader) fail(ctx context.Context, err error) {
+	select {
+	case r.errs <- err:
+	default:
+	}
+
+	select {
+	case <-ctx.Done():
+	case <-time.After(retryBackoff):
+	}
+}
+
+// subscribe cria ou atualiza o consumer durável do tópico e se vincula a ele
+func (r *reader) subscribe(topic string) (*nats.Subscription, error) {
+	b := r.broker
+	durable := invalidDurableChars.ReplaceAllString(r.groupID+"__"+topic, "_")
+
+	config := &nats.ConsumerConfig{
+		Durable:       durable,
+		FilterSubject: b.subject(topic),
+		AckPolicy:     nats.AckExplicitPolicy,
+		AckWait:       b.config.AckWait,
+		MaxAckPending: maxAckPending,
+		DeliverPolicy: nats.DeliverAllPolicy,
+	}
+
+	_, err := b.js.ConsumerInfo(b.config.Stream, durable)
+	switch {
+	case errors.Is(err, nats.ErrConsumerNotFound):
+		_, err = b.js.AddConsumer(b.config.Stream, config)
+	case err == nil:
+		// Mantém o consumer alinhado à configuração atual (ex: AckWait alterado)
+		_, err = b.js.UpdateConsumer(b.config.Stream, config)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao criar o consumer %s: %w", durable, err)
+	}
+
+	sub, err := b.js.PullSubscribe(b.subject(topic), durable, nats.Bind(b.config.Stream, durable))
+	if err != nil {
+		return nil, fmt.Errorf("erro ao vincular ao consumer %s: %w", durable, err)
+	}
+	return sub, nil
+}
+
+// toKafkaMessage converte a mensagem do JetStream, restaurando chave e headers
+func (r *reader) toKafkaMessage(msg *nats.Msg) (kafka.Message, uint64, error) {
+	meta, err := msg.Metadata()
+	if err != nil {
+		return kafka.Message{}, 0, err
+	}
+
+	message := kafka.Message{
+		Topic:     r.broker.topic(msg.Subject),
+		Partition: 0,
+		Offset:    int64(meta.Sequence.Stream),
+		Value:     msg.Data,
+		Time:      meta.Timestamp,
+	}
+
+	keys := make([]string, 0, len(msg.Header))
+	for key := range msg.Header {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if key == HeaderMessageKey {
+			if message.Key, err = base64.StdEncoding.DecodeString(msg.Header.Get(key)); err != nil {
+				return kafka.Message{}, 0, fmt.Errorf("chave inválida: %w", err)
+			}
+			continue
+		}
+		for _, value := range msg.Header.Values(key) {
+			message.Headers = append(message.Headers, kafka.Header{Key: key, Value: []byte(value)})
+		}
+	}
+
+	return message, meta.Sequence.Stream, nil
+}
+
+// CommitMessages confirma, como no Kafka, cada mensagem informada e todas as anteriores do mesmo
+// tópico entregues a este reader
+func (r *reader) CommitMessages(ctx context.Context, messages ...kafka.Message) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return io.ErrClosedPipe
+	}
+
+	for _, message := range messages {
+		pending := r.pending[message.Topic]
+
+		acked := 0
+		for acked < len(pending) && pending[acked].sequence <= uint64(message.Offset) {
+			if err := pending[acked].msg.Ack(); err != nil {
+				r.pending[message.Topic] = pending[acked:]
+				return fmt.Errorf("erro ao confirmar mensagem %d: %w", pending[acked].sequence, err)
+			}
+			delete(r.inFlight, pending[acked].msg)
+			acked++
+		}
+		r.pending[message.Topic] = pending[acked:]
+	}
+	return nil
+}
+
+// Close para a leitura e devolve ao servidor as mensagens não confirmadas, para reentrega
+// imediata. Os consumers continuam existindo com o progresso do grupo.
+func (r *reader) Close() error {
+	r.mu.Lock()
+	if r.closed {
+		r.mu.Unlock()
+		return nil
+	}
+	r.closed = true
+	close(r.done)
+	r.mu.Unlock()
+
+	// Sincroniza com startPollers; se a leitura nunca começou, impede que comece
+	r.start.Do(func() {})
+	if r.stop != nil {
+		r.stop()
+		r.workers.Wait()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Entregues ao Router ou ainda no buffer: todas as não confirmadas estão em inFlight. A
+	// devolução segue a ordem da stream para que a reentrega preserve a ordem do tópico.
+	held := make([]*nats.Msg, 0, len(r.inFlight))
+	for msg := range r.inFlight {
+		held = append(held, msg)
+	}
+	sort.Slice(held, func(i, j int) bool { return streamSequence(held[i]) < streamSequence(held[j]) })
+	for _, msg := range held {
+		msg.Nak()
+	}
+	r.inFlight = nil
+	r.pending = nil
+	return nil
+}
+
+// streamSequence retorna a posição da mensagem na stream (0 se não vier do JetStream)
+func streamSequence(msg *nats.Msg) uint64 {
+	metadata, err := msg.Metadata()
+	if err != nil {
+		return 0
+	}
+	return metadata.Sequence.Stream
+}
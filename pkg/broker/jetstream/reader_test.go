@@ -0,0 +1,221 @@
+package jetstream
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	pkgkafka "pkg/kafka"
+
+	"github.com/nats-io/nats.go"
+	"github.com/rs/zerolog"
+	"github.com/segmentio/kafka-go"
+)
+
+func TestMain(m *testing.M) {
+	zerolog.SetGlobalLevel(zerolog.Disabled)
+	os.Exit(m.Run())
+}
+
+// newTestBroker inicia um nats-server embutido com o AckWait informado
+func newTestBroker(t *testing.T, ackWait time.Duration) *Broker {
+	t.Helper()
+
+	b, err := NewBroker(Config{Embedded: true, StoreDir: t.TempDir(), AckWait: ackWait})
+	if err != nil {
+		t.Fatalf("NewBroker: %v", err)
+	}
+	t.Cleanup(func() { b.Close() })
+	return b
+}
+
+// publish publica count mensagens com chaves distintas no tópico
+func publish(t *testing.T, b *Broker, topic string, count int) {
+	t.Helper()
+
+	messages := make([]kafka.Message, count)
+	for i := range messages {
+		messages[i] = kafka.Message{
+			Topic: topic,
+			Key:   []byte(fmt.Sprintf("key-%d", i)),
+			Value: []byte(fmt.Sprintf("value-%d", i)),
+		}
+	}
+	if err := b.NewWriter().WriteMessages(context.Background(), messages...); err != nil {
+		t.Fatalf("WriteMessages: %v", err)
+	}
+}
+
+// fetch lê uma mensagem, retornando false se nenhuma chegar dentro do prazo
+func fetch(t *testing.T, r pkgkafka.Reader, timeout time.Duration) (kafka.Message, bool) {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	message, err := r.FetchMessage(ctx)
+	if errors.Is(err, context.DeadlineExceeded) {
+		return kafka.Message{}, false
+	}
+	if err != nil {
+		t.Fatalf("FetchMessage: %v", err)
+	}
+	return message, true
+}
+
+// consumerInfo retorna o estado do consumer durável do grupo para o tópico
+func consumerInfo(t *testing.T, b *Broker, groupID, topic string) *nats.ConsumerInfo {
+	t.Helper()
+
+	durable := invalidDurableChars.ReplaceAllString(groupID+"__"+topic, "_")
+	info, err := b.js.ConsumerInfo(b.config.Stream, durable)
+	if err != nil {
+		t.Fatalf("ConsumerInfo: %v", err)
+	}
+	return info
+}
+
+func TestFetchPreservesKeyAndHeaders(t *testing.T) {
+	b := newTestBroker(t, time.Minute)
+
+	err := b.NewWriter().WriteMessages(context.Background(), kafka.Message{
+		Topic: "order.created",
+		Key:   []byte{0x00, 0xff, 'k'},
+		Value: []byte(`{"id":42}`),
+		Headers: []kafka.Header{
+			{Key: "correlation_id", Value: []byte("abc")},
+			{Key: "tag", Value: []byte("a")},
+			{Key: "tag", Value: []byte("b")},
+		},
+	})
+	if err != nil {
+		t.Fatalf("WriteMessages: %v", err)
+	}
+
+	reader := b.NewGroupReader([]string{"order.created"}, "query-service")
+	defer reader.Close()
+
+	message, ok := fetch(t, reader, 5*time.Second)
+	if !ok {
+		t.Fatal("mensagem não entregue")
+	}
+	if message.Topic != "order.created" || string(message.Key) != "\x00\xffk" || string(message.Value) != `{"id":42}` {
+		t.Errorf("mensagem %s/%q/%s", message.Topic, message.Key, message.Value)
+	}
+	want := []kafka.Header{
+		{Key: "correlation_id", Value: []byte("abc")},
+		{Key: "tag", Value: []byte("a")},
+		{Key: "tag", Value: []byte("b")},
+	}
+	if len(message.Headers) != len(want) {
+		t.Fatalf("headers %v, esperado %v", message.Headers, want)
+	}
+	for i, header := range message.Headers {
+		if !strings.EqualFold(header.Key, want[i].Key) || string(header.Value) != string(want[i].Value) {
+			t.Errorf("header %d = %s:%s, esperado %s:%s", i, header.Key, header.Value, want[i].Key, want[i].Value)
+		}
+	}
+}
+
+func TestUncommittedMessagesAreRedeliveredAfterClose(t *testing.T) {
+	b := newTestBroker(t, time.Minute)
+	publish(t, b, "order.created", 3)
+
+	reader := b.NewGroupReader([]string{"order.created"}, "query-service")
+	var fetched []kafka.Message
+	for i := 0; i < 3; i++ {
+		message, ok := fetch(t, reader, 5*time.Second)
+		if !ok {
+			t.Fatalf("mensagem %d não entregue", i)
+		}
+		fetched = append(fetched, message)
+	}
+	if err := reader.CommitMessages(context.Background(), fetched[0]); err != nil {
+		t.Fatalf("CommitMessages: %v", err)
+	}
+	reader.Close()
+
+	// O novo membro recebe apenas as mensagens não confirmadas
+	reopened := b.NewGroupReader([]string{"order.created"}, "query-service")
+	defer reopened.Close()
+
+	for _, want := range fetched[1:] {
+		message, ok := fetch(t, reopened, 5*time.Second)
+		if !ok {
+			t.Fatalf("mensagem %d não reentregue", want.Offset)
+		}
+		if message.Offset != want.Offset {
+			t.Errorf("reentregue a mensagem %d, esperado %d", message.Offset, want.Offset)
+		}
+		reopened.CommitMessages(context.Background(), message)
+	}
+	if message, ok := fetch(t, reopened, 500*time.Millisecond); ok {
+		t.Errorf("mensagem %d entregue novamente", message.Offset)
+	}
+}
+
+func TestHeldMessagesAreNotRedelivered(t *testing.T) {
+	const ackWait = time.Second
+	b := newTestBroker(t, ackWait)
+	publish(t, b, "order.created", 2)
+
+	reader := b.NewGroupReader([]string{"order.created"}, "query-service")
+	defer reader.Close()
+
+	// A primeira fica com o Router (ex: no atraso de um nível de retry) e a segunda no buffer do
+	// reader por várias vezes o AckWait
+	first, ok := fetch(t, reader, 5*time.Second)
+	if !ok {
+		t.Fatal("mensagem não entregue")
+	}
+	time.Sleep(3 * ackWait)
+
+	second, ok := fetch(t, reader, 5*time.Second)
+	if !ok {
+		t.Fatal("segunda mensagem não entregue")
+	}
+	if second.Offset == first.Offset {
+		t.Fatalf("mensagem %d reentregue enquanto retida", first.Offset)
+	}
+	if message, ok := fetch(t, reader, 500*time.Millisecond); ok {
+		t.Fatalf("mensagem %d reentregue enquanto retida", message.Offset)
+	}
+
+	if info := consumerInfo(t, b, "query-service", "order.created"); info.NumRedelivered != 0 {
+		t.Errorf("%d mensagens reentregues pelo servidor, esperado 0", info.NumRedelivered)
+	}
+
+	if err := reader.CommitMessages(context.Background(), second); err != nil {
+		t.Fatalf("CommitMessages: %v", err)
+	}
+	// As confirmações são assíncronas; aguarda o servidor processá-las
+	deadline := time.Now().Add(5 * time.Second)
+	for info := consumerInfo(t, b, "query-service", "order.created"); info.NumAckPending != 0; info = consumerInfo(t, b, "query-service", "order.created") {
+		if time.Now().After(deadline) {
+			t.Fatalf("%d mensagens sem confirmação, esperado 0", info.NumAckPending)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestReaderFetchesOnlyOnDemand(t *testing.T) {
+	b := newTestBroker(t, time.Minute)
+	publish(t, b, "order.created", 3*fetchBatch)
+
+	reader := b.NewGroupReader([]string{"order.created"}, "query-service")
+	defer reader.Close()
+
+	if _, ok := fetch(t, reader, 5*time.Second); !ok {
+		t.Fatal("mensagem não entregue")
+	}
+
+	// Sem novos pedidos do Router, o reader não busca além do lote pedido
+	time.Sleep(500 * time.Millisecond)
+	if info := consumerInfo(t, b, "query-service", "order.created"); info.NumAckPending > fetchBatch {
+		t.Errorf("%d mensagens buscadas sem pedido do Router, esperado no máximo %d", info.NumAckPending, fetchBatch)
+	}
+}
@@ -0,0 +1,75 @@
+package jetstream
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/segmentio/kafka-go"
+)
+
+// HeaderMessageKey header NATS que transporta a chave da mensagem Kafka (em base64, sem perdas)
+const HeaderMessageKey = "Message-Key"
+
+// publishTimeout prazo da confirmação de cada publicação quando o contexto não define um
+const publishTimeout = 5 * time.Second
+
+// writer publica mensagens na stream, um subject por tópico
+type writer struct {
+	broker *Broker
+}
+
+// WriteMessages publica as mensagens aguardando a confirmação da stream. Em falha parcial retorna
+// kafka.WriteErrors, como o writer Kafka, para que o Producer identifique as mensagens com erro.
+func (w *writer) WriteMessages(ctx context.Context, messages ...kafka.Message) error {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, publishTimeout)
+		defer cancel()
+	}
+
+	errs := make(kafka.WriteErrors, len(messages))
+	failed := 0
+	for i, message := range messages {
+		if message.Topic == "" {
+			errs[i] = errors.New("mensagem sem tópico")
+			failed++
+			continue
+		}
+
+		if _, err := w.broker.js.PublishMsg(w.toNatsMessage(message), nats.Context(ctx)); err != nil {
+			errs[i] = fmt.Errorf("erro ao publicar no subject %s: %w", w.broker.subject(message.Topic), err)
+			failed++
+		}
+	}
+
+	switch {
+	case failed == 0:
+		return nil
+	case len(messages) == 1:
+		return errs[0]
+	default:
+		return errs
+	}
+}
+
+// toNatsMessage converte a mensagem Kafka mantendo chave e headers (inclusive repetidos)
+func (w *writer) toNatsMessage(message kafka.Message) *nats.Msg {
+	msg := nats.NewMsg(w.broker.subject(message.Topic))
+	msg.Data = message.Value
+	if message.Key != nil {
+		msg.Header.Set(HeaderMessageKey, base64.StdEncoding.EncodeToString(message.Key))
+	}
+	for _, header := range message.Headers {
+		msg.Header.Add(header.Key, string(header.Value))
+	}
+	return msg
+}
+
+// Close não tem efeito: a conexão pertence ao Broker
+func (w *writer) Close() error {
+	return nil
+}
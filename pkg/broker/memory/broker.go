@@ -0,0 +1,289 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"time"
+	pkgkafka "pkg/kafka"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// ErrBrokerClosed retornado ao publicar em um broker fechado
+var ErrBrokerClosed = errors.New("broker em memória fechado")
+
+// partitionKey identifica uma partição de um tópico
+type partitionKey struct {
+	topic     string
+	partition int
+}
+
+// Broker broker em processo para testes e desenvolvimento local. Implementa pkgkafka.Broker com
+// tópicos particionados (criados no primeiro uso), consumer groups e offsets confirmados, então o
+// Producer e o Router funcionam sobre ele como sobre o Kafka, inclusive retry e DLQ (que são
+// apenas tópicos). Cada partição é lida por um único membro do grupo por vez; ao fechar um reader,
+// as mensagens não confirmadas voltam a ser entregues a partir do último offset confirmado.
+type Broker struct {
+	mu         sync.Mutex
+	partitions int
+	balancer   kafka.Hash // Mesma chave -> mesma partição, como no Producer Kafka
+	topics     map[string][][]kafka.Message
+	groups     map[string]*group
+	changed    chan struct{} // Fechado (e recriado) a cada mudança, para acordar os readers
+	closed     bool
+}
+
+// group estado de um consumer group
+type group struct {
+	committed map[partitionKey]int64   // Próximo offset a ler após reatribuição
+	position  map[partitionKey]int64   // Próximo offset a entregar ao dono atual
+	owner     map[partitionKey]*reader // Membro que lê a partição
+}
+
+// NewBroker cria um broker em memória; tópicos são criados com o número de partições informado
+func NewBroker(partitions int) *Broker {
+	if partitions < 1 {
+		partitions = 1
+	}
+	return &Broker{
+		partitions: partitions,
+		topics:     make(map[string][][]kafka.Message),
+		groups:     make(map[string]*group),
+		changed:    make(chan struct{}),
+	}
+}
+
+// NewWriter cria um writer que publica nos tópicos do broker
+func (b *Broker) NewWriter() pkgkafka.Writer {
+	return &writer{broker: b}
+}
+
+// NewGroupReader cria um membro do consumer group para os tópicos informados
+func (b *Broker) NewGroupReader(topics []string, groupID string) pkgkafka.Reader {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var keys []partitionKey
+	for _, topic := range topics {
+		for partition := range b.topicLocked(topic) {
+			keys = append(keys, partitionKey{topic: topic, partition: partition})
+		}
+	}
+
+	g, ok := b.groups[groupID]
+	if !ok {
+		g = &group{
+			committed: make(map[partitionKey]int64),
+			position:  make(map[partitionKey]int64),
+			owner:     make(map[partitionKey]*reader),
+		}
+		b.groups[groupID] = g
+	}
+
+	return &reader{broker: b, group: g, keys: keys}
+}
+
+// Messages retorna as mensagens publicadas no tópico, por partição e em ordem de offset
+func (b *Broker) Messages(topic string) []kafka.Message {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var messages []kafka.Message
+	for _, log := range b.topics[topic] {
+		messages = append(messages, log...)
+	}
+	return messages
+}
+
+// CommittedOffset retorna o próximo offset que o grupo lerá na partição (0 se nada foi confirmado)
+func (b *Broker) CommittedOffset(groupID, topic string, partition int) int64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	g, ok := b.groups[groupID]
+	if !ok {
+		return 0
+	}
+	return g.committed[partitionKey{topic: topic, partition: partition}]
+}
+
+// Close fecha o broker: publicações falham e os readers recebem io.EOF
+func (b *Broker) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+	b.notifyLocked()
+	return nil
+}
+
+// topicLocked retorna as partições do tópico, criando-o se necessário
+func (b *Broker) topicLocked(topic string) [][]kafka.Message {
+	partitions, ok := b.topics[topic]
+	if !ok {
+		partitions = make([][]kafka.Message, b.partitions)
+		b.topics[topic] = partitions
+	}
+	return partitions
+}
+
+// notifyLocked acorda os readers que aguardam mensagens
+func (b *Broker) notifyLocked() {
+	close(b.changed)
+	b.changed = make(chan struct{})
+}
+
+// writer publica mensagens no broker em memória
+type writer struct {
+	broker *Broker
+}
+
+// WriteMessages anexa as mensagens às partições escolhidas pela chave
+func (w *writer) WriteMessages(ctx context.Context, messages ...kafka.Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	b := w.broker
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return ErrBrokerClosed
+	}
+
+	for _, message := range messages {
+		if message.Topic == "" {
+			return errors.New("mensagem sem tópico")
+		}
+
+		partitions := b.topicLocked(message.Topic)
+		ids := make([]int, len(partitions))
+		for i := range ids {
+			ids[i] = i
+		}
+
+		message.Partition = b.balancer.Balance(message, ids...)
+		message.Offset = int64(len(partitions[message.Partition]))
+		if message.Time.IsZero() {
+			message.Time = time.Now()
+		}
+		partitions[message.Partition] = append(partitions[message.Partition], message)
+	}
+
+	b.notifyLocked()
+	return nil
+}
+
+// Close não tem efeito: o writer não mantém recursos próprios
+func (w *writer) Close() error {
+	return nil
+}
+
+// reader membro de um consumer group no broker em memória
+type reader struct {
+	broker *Broker
+	group  *group
+	keys   []partitionKey // Partições dos tópicos assinados
+	cursor int            // Próxima partição a verificar, para alternar entre elas
+	closed bool
+}
+
+// FetchMessage aguarda e retorna a próxima mensagem de uma partição livre ou já atribuída a este
+// reader. Não confirma o offset.
+func (r *reader) FetchMessage(ctx context.Context) (kafka.Message, error) {
+	b := r.broker
+	for {
+		b.mu.Lock()
+		if r.closed || b.closed {
+			b.mu.Unlock()
+			return kafka.Message{}, io.EOF
+		}
+		if message, ok := r.nextLocked(); ok {
+			b.mu.Unlock()
+			return message, nil
+		}
+		changed := b.changed
+		b.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return kafka.Message{}, ctx.Err()
+		case <-changed:
+		}
+	}
+}
+
+// nextLocked percorre as partições a partir do cursor e entrega a primeira mensagem disponível
+func (r *reader) nextLocked() (kafka.Message, bool) {
+	for i := 0; i < len(r.keys); i++ {
+		index := (r.cursor + i) % len(r.keys)
+		key := r.keys[index]
+
+		if owner, ok := r.group.owner[key]; ok && owner != r {
+			continue
+		}
+
+		position, ok := r.group.position[key]
+		if !ok {
+			position = r.group.committed[key]
+		}
+
+		log := r.broker.topics[key.topic][key.partition]
+		if position >= int64(len(log)) {
+			continue
+		}
+
+		r.group.owner[key] = r
+		r.group.position[key] = position + 1
+		r.cursor = index + 1
+
+		message := log[position]
+		message.HighWaterMark = int64(len(log))
+		return message, true
+	}
+	return kafka.Message{}, false
+}
+
+// CommitMessages confirma os offsets das mensagens para o grupo
+func (r *reader) CommitMessages(ctx context.Context, messages ...kafka.Message) error {
+	b := r.broker
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if r.closed {
+		return io.ErrClosedPipe
+	}
+
+	for _, message := range messages {
+		key := partitionKey{topic: message.Topic, partition: message.Partition}
+		if next := message.Offset + 1; next > r.group.committed[key] {
+			r.group.committed[key] = next
+		}
+	}
+	return nil
+}
+
+// Close libera as partições do reader; a leitura é retomada do último offset confirmado
+func (r *reader) Close() error {
+	b := r.broker
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if r.closed {
+		return nil
+	}
+	r.closed = true
+
+	for key, owner := range r.group.owner {
+		if owner == r {
+			delete(r.group.owner, key)
+			delete(r.group.position, key)
+		}
+	}
+
+	b.notifyLocked()
+	return nil
+}
@@ -0,0 +1,295 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	pkgkafka "pkg/kafka"
+
+	"github.com/rs/zerolog"
+	"github.com/segmentio/kafka-go"
+)
+
+func TestMain(m *testing.M) {
+	zerolog.SetGlobalLevel(zerolog.Disabled)
+	os.Exit(m.Run())
+}
+
+// publish publica count mensagens com chaves distintas no tópico
+func publish(t *testing.T, b *Broker, topic string, count int) {
+	t.Helper()
+
+	messages := make([]kafka.Message, count)
+	for i := range messages {
+		messages[i] = kafka.Message{
+			Topic: topic,
+			Key:   []byte(fmt.Sprintf("key-%d", i)),
+			Value: []byte(fmt.Sprintf("value-%d", i)),
+		}
+	}
+	if err := b.NewWriter().WriteMessages(context.Background(), messages...); err != nil {
+		t.Fatalf("WriteMessages: %v", err)
+	}
+}
+
+// fetch lê uma mensagem, retornando false se nenhuma estiver disponível para o reader
+func fetch(t *testing.T, r pkgkafka.Reader) (kafka.Message, bool) {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	message, err := r.FetchMessage(ctx)
+	if errors.Is(err, context.DeadlineExceeded) {
+		return kafka.Message{}, false
+	}
+	if err != nil {
+		t.Fatalf("FetchMessage: %v", err)
+	}
+	return message, true
+}
+
+// drain lê e confirma todas as mensagens disponíveis para o reader
+func drain(t *testing.T, r pkgkafka.Reader) []kafka.Message {
+	t.Helper()
+
+	var messages []kafka.Message
+	for {
+		message, ok := fetch(t, r)
+		if !ok {
+			return messages
+		}
+		if err := r.CommitMessages(context.Background(), message); err != nil {
+			t.Fatalf("CommitMessages: %v", err)
+		}
+		messages = append(messages, message)
+	}
+}
+
+func TestConsumerGroupsFanOut(t *testing.T) {
+	b := NewBroker(3)
+	defer b.Close()
+
+	publish(t, b, "order.created", 20)
+
+	// Cada grupo recebe todas as mensagens do tópico
+	for _, groupID := range []string{"query-service", "product-service"} {
+		reader := b.NewGroupReader([]string{"order.created"}, groupID)
+		if got := len(drain(t, reader)); got != 20 {
+			t.Errorf("grupo %s recebeu %d mensagens, esperado 20", groupID, got)
+		}
+		reader.Close()
+	}
+}
+
+func TestConsumerGroupSharesPartitions(t *testing.T) {
+	b := NewBroker(3)
+	defer b.Close()
+
+	publish(t, b, "order.created", 30)
+
+	first := b.NewGroupReader([]string{"order.created"}, "query-service")
+	second := b.NewGroupReader([]string{"order.created"}, "query-service")
+	defer first.Close()
+	defer second.Close()
+
+	// Os membros leem alternadamente; cada partição fica com um único membro
+	readers := []pkgkafka.Reader{first, second}
+	owners := make(map[int]int)
+	seen := make(map[string]bool)
+	received := make([]int, len(readers))
+	for idle := 0; idle < len(readers); {
+		idle = 0
+		for i, reader := range readers {
+			message, ok := fetch(t, reader)
+			if !ok {
+				idle++
+				continue
+			}
+
+			if owner, ok := owners[message.Partition]; ok && owner != i {
+				t.Fatalf("partição %d lida pelos membros %d e %d", message.Partition, owner, i)
+			}
+			owners[message.Partition] = i
+
+			id := fmt.Sprintf("%d/%d", message.Partition, message.Offset)
+			if seen[id] {
+				t.Fatalf("mensagem %s entregue mais de uma vez", id)
+			}
+			seen[id] = true
+			received[i]++
+			reader.CommitMessages(context.Background(), message)
+		}
+	}
+
+	if len(seen) != 30 {
+		t.Errorf("grupo recebeu %d mensagens, esperado 30", len(seen))
+	}
+	for i, count := range received {
+		if count == 0 {
+			t.Errorf("membro %d não recebeu mensagens", i)
+		}
+	}
+}
+
+func TestUncommittedMessagesAreRedeliveredAfterClose(t *testing.T) {
+	b := NewBroker(1)
+	defer b.Close()
+
+	publish(t, b, "order.created", 5)
+
+	reader := b.NewGroupReader([]string{"order.created"}, "query-service")
+	var fetched []kafka.Message
+	for i := 0; i < 4; i++ {
+		message, ok := fetch(t, reader)
+		if !ok {
+			t.Fatalf("mensagem %d não entregue", i)
+		}
+		fetched = append(fetched, message)
+	}
+	// Só as duas primeiras são confirmadas
+	if err := reader.CommitMessages(context.Background(), fetched[1]); err != nil {
+		t.Fatalf("CommitMessages: %v", err)
+	}
+	reader.Close()
+
+	if got := b.CommittedOffset("query-service", "order.created", 0); got != 2 {
+		t.Fatalf("offset confirmado %d, esperado 2", got)
+	}
+
+	// O novo membro retoma do último offset confirmado
+	reopened := b.NewGroupReader([]string{"order.created"}, "query-service")
+	defer reopened.Close()
+
+	redelivered := drain(t, reopened)
+	if len(redelivered) != 3 {
+		t.Fatalf("%d mensagens reentregues, esperado 3", len(redelivered))
+	}
+	for i, message := range redelivered {
+		if want := int64(i + 2); message.Offset != want {
+			t.Errorf("mensagem %d com offset %d, esperado %d", i, message.Offset, want)
+		}
+	}
+}
+
+func TestRouterRetryThenDLQ(t *testing.T) {
+	b := NewBroker(3)
+	defer b.Close()
+
+	producer := pkgkafka.NewProducerWithBroker(b)
+	router := pkgkafka.NewRouterWithBroker(b, "query-service", producer)
+	delays := []time.Duration{10 * time.Millisecond, 20 * time.Millisecond}
+	router.SetRetryDelays(delays)
+
+	var mu sync.Mutex
+	attempts := 0
+	router.Handle("order.created", func(ctx context.Context, message []byte) error {
+		mu.Lock()
+		defer mu.Unlock()
+		attempts++
+		return errors.New("mongo indisponível")
+	})
+
+	dead := make(chan pkgkafka.DLQEnvelope, 1)
+	router.SetHooks(pkgkafka.RouterHooks{
+		OnDLQ: func(envelope pkgkafka.DLQEnvelope) { dead <- envelope },
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- router.Run(ctx) }()
+
+	if err := producer.Publish(ctx, "order.created", []byte("order:42"), []byte(`{"id":42}`), nil); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+
+	var envelope pkgkafka.DLQEnvelope
+	select {
+	case envelope = <-dead:
+	case <-time.After(5 * time.Second):
+		t.Fatal("mensagem não chegou à DLQ")
+	}
+	cancel()
+	if err := <-done; err != nil && !errors.Is(err, context.Canceled) {
+		t.Fatalf("Run: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if attempts != 3 {
+		t.Errorf("handler chamado %d vezes, esperado 3 (tópico principal + 2 retries)", attempts)
+	}
+	for _, delay := range delays {
+		topic := pkgkafka.RetryTopic("order.created", "query-service", delay)
+		if got := len(b.Messages(topic)); got != 1 {
+			t.Errorf("%d mensagens em %s, esperado 1", got, topic)
+		}
+	}
+	if got := len(b.Messages(pkgkafka.DLQTopic("order.created", "query-service"))); got != 1 {
+		t.Errorf("%d mensagens na DLQ, esperado 1", got)
+	}
+	if envelope.OriginalTopic != "order.created" || string(envelope.Key) != "order:42" {
+		t.Errorf("envelope com origem %s e chave %s", envelope.OriginalTopic, envelope.Key)
+	}
+	if envelope.ErrorMessage != "mongo indisponível" {
+		t.Errorf("envelope com erro %q", envelope.ErrorMessage)
+	}
+}
+
+func TestRouterForwardsMessageAfterFailedProbes(t *testing.T) {
+	b := NewBroker(3)
+	defer b.Close()
+
+	producer := pkgkafka.NewProducerWithBroker(b)
+	router := pkgkafka.NewRouterWithBroker(b, "query-service", producer)
+	router.SetRetryDelays([]time.Duration{})
+	router.SetCircuitBreaker(pkgkafka.NewCircuitBreaker(pkgkafka.CircuitBreakerConfig{
+		FailureThreshold: 1,
+		OpenTimeout:      10 * time.Millisecond,
+		MaxProbes:        2,
+	}))
+
+	var mu sync.Mutex
+	attempts := 0
+	router.Handle("order.created", func(ctx context.Context, message []byte) error {
+		mu.Lock()
+		defer mu.Unlock()
+		attempts++
+		return errors.New("documento inválido para o mongo")
+	})
+
+	dead := make(chan pkgkafka.DLQEnvelope, 1)
+	router.SetHooks(pkgkafka.RouterHooks{
+		OnDLQ: func(envelope pkgkafka.DLQEnvelope) { dead <- envelope },
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- router.Run(ctx) }()
+
+	if err := producer.Publish(ctx, "order.created", []byte("order:42"), []byte(`{"id":42}`), nil); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+
+	// A mensagem que sempre falha abre o circuito, falha nas 2 sondagens e segue para a DLQ
+	select {
+	case <-dead:
+	case <-time.After(5 * time.Second):
+		t.Fatal("mensagem retida pelo circuito sem limite de sondagens")
+	}
+	cancel()
+	if err := <-done; err != nil && !errors.Is(err, context.Canceled) {
+		t.Fatalf("Run: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if attempts != 3 {
+		t.Errorf("handler chamado %d vezes, esperado 3 (falha que abre o circuito + 2 sondagens)", attempts)
+	}
+}
@@ -0,0 +1,81 @@
+package broker
+
+import (
+	"context"
+	"fmt"
+	pkgconfig "pkg/config"
+	pkgkafka "pkg/kafka"
+
+	"github.com/rs/zerolog/log"
+)
+
+// TopicRegistry monta o registry dos tópicos de eventos, retry e DLQ a partir da configuração.
+// Além dos tópicos de eventos lidos pelos consumidores, registra os tópicos em que a outbox publica
+// com OUTBOX_TOPIC_PREFIX e OUTBOX_TOPIC_ROUTES, para que existam mesmo sem a criação automática de
+// tópicos do Kafka. O tópico de destino de um evento recebe os tópicos de retry e DLQ dos consumer
+// groups que leem o evento.
+func TopicRegistry(config *pkgconfig.Config) (*pkgkafka.TopicRegistry, error) {
+	retryDelays, err := pkgkafka.ParseRetryDelays(config.KafkaRetryDelays)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao interpretar KAFKA_RETRY_DELAYS: %w", err)
+	}
+	rules, err := pkgkafka.ParseTopicRules(config.OutboxTopicRoutes)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao interpretar OUTBOX_TOPIC_ROUTES: %w", err)
+	}
+
+	defaults := topicDefaults(config)
+	registry := pkgkafka.DefaultTopicRegistry(defaults, retryDelays)
+
+	topicRules := pkgkafka.TopicRules{Prefix: config.OutboxTopicPrefix, Rules: rules}
+	for _, eventType := range pkgkafka.EventTopics {
+		topic := topicRules.TopicFor(eventType)
+		registry.RegisterEventTopic(topic, pkgkafka.ConsumerGroupsOf(eventType), retryDelays, defaults)
+	}
+	// Destinos de regras que nenhum evento conhecido alcança: apenas o tópico
+	for _, topic := range topicRules.Topics(pkgkafka.EventTopics) {
+		if _, ok := registry.Lookup(topic); !ok {
+			registry.RegisterEventTopic(topic, nil, retryDelays, defaults)
+		}
+	}
+	return registry, nil
+}
+
+// topicDefaults partições, replicação e retenções dos tópicos registrados (KAFKA_TOPIC_*)
+func topicDefaults(config *pkgconfig.Config) pkgkafka.TopicDefaults {
+	return pkgkafka.TopicDefaults{
+		Partitions:        config.KafkaTopicPartitions,
+		ReplicationFactor: config.KafkaTopicReplicationFactor,
+		Retention:         config.GetKafkaTopicRetention(),
+		DLQRetention:      config.GetKafkaDLQRetention(),
+	}
+}
+
+// EnsureTopics cria os tópicos ausentes no Kafka e falha se algum existente divergir da
+// especificação (corrigido com migrate-topics). Os routers informados acrescentam os tópicos que
+// leem, com os tópicos de retry de cada rota (RouteOptions.RetryDelays). Sem efeito com outros
+// backends ou com KAFKA_TOPICS_RECONCILE=false.
+func EnsureTopics(ctx context.Context, config *pkgconfig.Config, routers ...*pkgkafka.Router) error {
+	if (config.BrokerBackend != BackendKafka && config.BrokerBackend != "") || !config.KafkaTopicsReconcile {
+		return nil
+	}
+
+	registry, err := TopicRegistry(config)
+	if err != nil {
+		return err
+	}
+	for _, router := range routers {
+		router.RegisterTopics(registry, topicDefaults(config))
+	}
+
+	plan, err := pkgkafka.NewTopicAdmin(config.GetKafkaBrokers()).Reconcile(ctx, registry)
+	if err != nil {
+		return err
+	}
+
+	log.Info().
+		Int("created", len(plan.Missing)).
+		Int("in_sync", len(plan.InSync)).
+		Msg("tópicos do Kafka conferidos")
+	return nil
+}
@@ -0,0 +1,182 @@
+// dlq-replay lista e republica mensagens de tópicos de DLQ.
+//
+//	dlq-replay list   -topic order.created -group query-consumer [-since 24h] [-error timeout] [-event-type order.created]
+//	dlq-replay replay -topic order.created -group query-consumer [-ids 0:15,1:20 | -all] [-dry-run] [-force]
+//
+// Cada consumer group tem a sua DLQ (<tópico>.<grupo>.dlq); -topic também aceita o nome completo
+// da DLQ, sem -group. As entradas republicadas são registradas em -journal e não são republicadas
+// de novo sem -force.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+	pkgbroker "pkg/broker"
+	pkgconfig "pkg/config"
+	pkgdlq "pkg/dlq"
+	pkgkafka "pkg/kafka"
+	pkglog "pkg/log"
+
+	"github.com/rs/zerolog/log"
+)
+
+func main() {
+	if len(os.Args) < 2 || (os.Args[1] != "list" && os.Args[1] != "replay") {
+		fmt.Fprintln(os.Stderr, "uso: dlq-replay <list|replay> -topic <tópico> -group <consumer group> [opções]")
+		os.Exit(2)
+	}
+	command := os.Args[1]
+
+	flags := flag.NewFlagSet(command, flag.ExitOnError)
+	topic := flags.String("topic", "", "tópico original ou de DLQ (ex: order.created ou order.created.query-consumer.dlq)")
+	group := flags.String("group", "", "consumer group que falhou (ex: query-consumer); dispensado com -topic da DLQ")
+	since := flags.String("since", "", "falhas a partir de (RFC3339 ou duração, ex: 24h)")
+	until := flags.String("until", "", "falhas até (RFC3339 ou duração, ex: 1h)")
+	errorContains := flags.String("error", "", "trecho do error_message")
+	eventType := flags.String("event-type", "", "event_type exato")
+	ids := flags.String("ids", "", "entradas selecionadas, partição:offset separados por vírgula")
+	all := flags.Bool("all", false, "republica todas as entradas filtradas")
+	dryRun := flags.Bool("dry-run", false, "mostra o que seria republicado sem publicar")
+	force := flags.Bool("force", false, "republica mesmo entradas já registradas no journal")
+	journalPath := flags.String("journal", "dlq-replays.ndjson", "arquivo NDJSON com o registro dos replays")
+	timeout := flags.Duration("timeout", time.Minute, "tempo máximo da operação")
+	flags.Parse(os.Args[2:])
+
+	config, err := pkgconfig.Load()
+	if err != nil {
+		log.Fatal().Err(err).Msg("erro ao carregar configuração")
+	}
+	pkglog.Setup("dlq-replay")
+
+	// A leitura da DLQ usa as APIs de partição e offset do Kafka, sem equivalente nos outros backends
+	if backend := config.BrokerBackend; backend != pkgbroker.BackendKafka && backend != "" {
+		log.Fatal().
+			Str("broker_backend", config.BrokerBackend).
+			Msg("dlq-replay suporta apenas BROKER_BACKEND=kafka")
+	}
+
+	if *topic == "" {
+		log.Fatal().Msg("informe -topic")
+	}
+	dlqTopic := *topic
+	if !strings.HasSuffix(dlqTopic, ".dlq") {
+		if *group == "" {
+			log.Fatal().Msg("informe -group ou o tópico completo da DLQ em -topic")
+		}
+		dlqTopic = pkgkafka.DLQTopic(dlqTopic, *group)
+	}
+
+	filter := pkgdlq.Filter{
+		ErrorContains: *errorContains,
+		EventType:     *eventType,
+	}
+	if filter.Since, err = parseTime(*since); err != nil {
+		log.Fatal().Err(err).Msg("valor inválido em -since")
+	}
+	if filter.Until, err = parseTime(*until); err != nil {
+		log.Fatal().Err(err).Msg("valor inválido em -until")
+	}
+	if filter.IDs, err = pkgdlq.ParseIDs(*ids); err != nil {
+		log.Fatal().Err(err).Msg("valor inválido em -ids")
+	}
+	if command == "replay" && len(filter.IDs) == 0 && !*all {
+		log.Fatal().Msg("informe -ids com as entradas a republicar ou -all")
+	}
+
+	journal, err := pkgdlq.OpenJournal(*journalPath)
+	if err != nil {
+		log.Fatal().Err(err).Msg("erro ao abrir journal")
+	}
+
+	kafkaBroker, err := pkgbroker.NewKafka(config)
+	if err != nil {
+		log.Fatal().Err(err).Msg("erro ao configurar o producer")
+	}
+	producer := pkgkafka.NewProducerWithBroker(kafkaBroker)
+	defer producer.Close()
+
+	replayer := pkgdlq.NewReplayer(config.GetKafkaBrokers(), producer, journal)
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	entries, err := replayer.List(ctx, dlqTopic, filter)
+	if err != nil {
+		log.Fatal().Err(err).Str("topic", dlqTopic).Msg("erro ao ler DLQ")
+	}
+
+	if command == "list" {
+		printEntries(entries, journal)
+		return
+	}
+
+	results := replayer.Replay(ctx, entries, *dryRun, *force)
+	if printResults(results, *dryRun) > 0 {
+		os.Exit(1)
+	}
+}
+
+// parseTime aceita RFC3339 ou uma duração relativa ao instante atual (ex: 24h = há 24 horas)
+func parseTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if duration, err := time.ParseDuration(value); err == nil {
+		return time.Now().Add(-duration), nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+// printEntries imprime as entradas da DLQ em formato de tabela
+func printEntries(entries []pkgdlq.Entry, journal *pkgdlq.Journal) {
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "ID\tÚLTIMA_FALHA\tTENTATIVAS\tEVENT_TYPE\tORIGEM\tHANDLER\tREPUBLICADA\tERRO")
+	for _, entry := range entries {
+		fmt.Fprintf(writer, "%s\t%s\t%d\t%s\t%s\t%s\t%t\t%s\n",
+			entry.ID(),
+			entry.LastFailureAt.Format(time.RFC3339),
+			entry.Attempts,
+			entry.EventType,
+			fmt.Sprintf("%s/%d/%d", entry.OriginalTopic, entry.OriginalPartition, entry.OriginalOffset),
+			entry.Handler,
+			journal.Contains(entry),
+			entry.ErrorMessage,
+		)
+	}
+	writer.Flush()
+	fmt.Printf("%d entrada(s)\n", len(entries))
+}
+
+// printResults imprime o resultado do replay e retorna a quantidade de falhas
+func printResults(results []pkgdlq.ReplayResult, dryRun bool) int {
+	replayed, skipped, failed := 0, 0, 0
+	for _, result := range results {
+		status := "republicada"
+		switch {
+		case result.Skipped:
+			status = "ignorada (já republicada)"
+			skipped++
+		case result.Err != nil:
+			status = "erro: " + result.Err.Error()
+			failed++
+		case dryRun:
+			status = "seria republicada"
+			replayed++
+		default:
+			replayed++
+		}
+		fmt.Printf("%s -> %s: %s\n", result.Entry.ID(), result.Entry.OriginalTopic, status)
+	}
+
+	fmt.Printf("republicadas: %d, ignoradas: %d, falhas: %d", replayed, skipped, failed)
+	if dryRun {
+		fmt.Print(" (dry-run)")
+	}
+	fmt.Println()
+	return failed
+}
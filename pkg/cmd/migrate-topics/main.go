@@ -0,0 +1,101 @@
+// migrate-topics cria e ajusta os tópicos do Kafka declarados em pkg/kafka (eventos, retry e DLQ).
+//
+//	migrate-topics [-dry-run] [-timeout 1m]
+//
+// Tópicos ausentes são criados; retenção e cleanup.policy divergentes são alterados e partições
+// faltantes são acrescentadas. Menos partições ou outro fator de replicação exigem recriar o tópico
+// e são apenas reportados (código de saída 1).
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+	pkgbroker "pkg/broker"
+	pkgconfig "pkg/config"
+	pkgkafka "pkg/kafka"
+	pkglog "pkg/log"
+
+	"github.com/rs/zerolog/log"
+)
+
+func main() {
+	dryRun := flag.Bool("dry-run", false, "mostra o plano sem alterar o cluster")
+	timeout := flag.Duration("timeout", time.Minute, "tempo máximo da operação")
+	flag.Parse()
+
+	config, err := pkgconfig.Load()
+	if err != nil {
+		log.Fatal().Err(err).Msg("erro ao carregar configuração")
+	}
+	pkglog.Setup("migrate-topics")
+
+	registry, err := pkgbroker.TopicRegistry(config)
+	if err != nil {
+		log.Fatal().Err(err).Msg("erro ao montar o registry de tópicos")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	admin := pkgkafka.NewTopicAdmin(config.GetKafkaBrokers())
+	if *dryRun {
+		plan, err := admin.Plan(ctx, registry)
+		if err != nil {
+			log.Fatal().Err(err).Msg("erro ao comparar os tópicos")
+		}
+		printPlan(plan)
+		return
+	}
+
+	plan, err := admin.Migrate(ctx, registry)
+	var mismatchErr *pkgkafka.TopicMismatchError
+	if err != nil && !errors.As(err, &mismatchErr) {
+		log.Fatal().Err(err).Msg("erro ao migrar os tópicos")
+	}
+	printPlan(plan)
+
+	if mismatchErr != nil {
+		fmt.Println("divergências que exigem recriar o tópico:")
+		for _, mismatch := range mismatchErr.Mismatches {
+			fmt.Println("  " + mismatch.String())
+		}
+		os.Exit(1)
+	}
+}
+
+// printPlan imprime as ações do plano em formato de tabela
+func printPlan(plan *pkgkafka.TopicPlan) {
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "TÓPICO\tAÇÃO\tDETALHE")
+	for _, spec := range plan.Missing {
+		fmt.Fprintf(writer, "%s\tcriar\tpartições=%d replicação=%d retenção=%s compactado=%t\n",
+			spec.Name, spec.Partitions, spec.ReplicationFactor, formatRetention(spec.Retention), spec.Compacted)
+	}
+	for _, mismatch := range plan.Mismatches {
+		action := "ajustar"
+		if !mismatch.Fixable {
+			action = "recriar"
+		}
+		fmt.Fprintf(writer, "%s\t%s\t%s: %s -> %s\n", mismatch.Topic, action, mismatch.Field, mismatch.Actual, mismatch.Expected)
+	}
+	writer.Flush()
+	fmt.Printf("a criar: %d, divergências: %d, conformes: %d\n", len(plan.Missing), len(plan.Mismatches), len(plan.InSync))
+}
+
+// formatRetention formata a retenção da especificação
+func formatRetention(retention time.Duration) string {
+	switch {
+	case retention == 0:
+		return "padrão"
+	case retention < 0:
+		return "infinita"
+	default:
+		return strconv.FormatFloat(retention.Hours(), 'f', -1, 64) + "h"
+	}
+}
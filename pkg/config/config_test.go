@@ -0,0 +1,56 @@
+package config
+
+import "testing"
+
+func TestValidateOutboxLease(t *testing.T) {
+	tests := []struct {
+		name         string
+		lease        string
+		writeTimeout string
+		maxAttempts  int
+		wantErr      bool
+	}{
+		{"padrão", "2m", "10s", 10, false},
+		{"igual ao pior tempo de publicação", "100s", "10s", 10, true},
+		{"menor que o pior tempo de publicação", "30s", "10s", 10, true},
+		{"sem tentativas configuradas vale uma escrita", "30s", "10s", 0, false},
+		{"escritas rápidas", "30s", "1s", 5, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := Config{
+				OutboxLeaseDuration:       tt.lease,
+				KafkaProducerWriteTimeout: tt.writeTimeout,
+				KafkaProducerMaxAttempts:  tt.maxAttempts,
+			}
+			if err := config.validateOutboxLease(); (err != nil) != tt.wantErr {
+				t.Errorf("validateOutboxLease() erro = %v, esperado erro = %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateOutboxRetryPolicy(t *testing.T) {
+	tests := []struct {
+		name        string
+		maxAttempts int
+		base        string
+		max         string
+		wantErr     bool
+	}{
+		{"padrão", 10, "1s", "5m", false},
+		{"uma tentativa", 1, "1s", "1s", false},
+		{"sem tentativas", 0, "1s", "5m", true},
+		{"máximo menor que a base", 10, "1m", "30s", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := Config{OutboxMaxAttempts: tt.maxAttempts, OutboxBackoffBase: tt.base, OutboxBackoffMax: tt.max}
+			if err := config.validateOutboxRetryPolicy(); (err != nil) != tt.wantErr {
+				t.Errorf("validateOutboxRetryPolicy() erro = %v, esperado erro = %v", err, tt.wantErr)
+			}
+		})
+	}
+}
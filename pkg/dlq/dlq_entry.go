@@ -0,0 +1,82 @@
+package dlq
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+	pkgevents "pkg/events"
+	pkgkafka "pkg/kafka"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// Entry mensagem lida de um tópico de DLQ
+type Entry struct {
+	Topic     string // Tópico de DLQ
+	Partition int    // Partição na DLQ
+	Offset    int64  // Offset na DLQ
+	EventType string
+	pkgkafka.DLQEnvelope
+}
+
+// ID identifica a entrada de forma única dentro do tópico de DLQ (partição:offset)
+func (e Entry) ID() string {
+	return formatID(e.Partition, e.Offset)
+}
+
+// legacyEnvelope formato antigo da DLQ, com o payload como string JSON em original_event
+type legacyEnvelope struct {
+	ErrorMessage  string          `json:"error_message"`
+	Timestamp     string          `json:"timestamp"`
+	OriginalEvent json.RawMessage `json:"original_event"`
+}
+
+// parseEntry converte uma mensagem da DLQ em Entry
+func parseEntry(message kafka.Message) (Entry, error) {
+	entry := Entry{
+		Topic:     message.Topic,
+		Partition: message.Partition,
+		Offset:    message.Offset,
+	}
+	if err := json.Unmarshal(message.Value, &entry.DLQEnvelope); err != nil {
+		return Entry{}, err
+	}
+
+	// Mensagens gravadas no formato antigo: sem chave, headers nem dados de origem
+	if entry.Value == nil {
+		var legacy legacyEnvelope
+		if err := json.Unmarshal(message.Value, &legacy); err != nil {
+			return Entry{}, err
+		}
+		entry.Value = originalPayload(legacy.OriginalEvent)
+		entry.Attempts = 1
+		entry.LastFailureAt = message.Time
+		if failedAt, err := time.Parse(time.RFC3339, legacy.Timestamp); err == nil {
+			entry.LastFailureAt = failedAt
+		}
+		entry.FirstFailureAt = entry.LastFailureAt
+	}
+	if entry.OriginalTopic == "" {
+		entry.OriginalTopic = strings.TrimSuffix(message.Topic, ".dlq")
+	}
+
+	// Sem header event_type, o tópico original é o event_type no roteamento padrão
+	entry.EventType = entry.OriginalTopic
+	for _, header := range entry.Headers {
+		if header.Key == pkgevents.HeaderEventType {
+			entry.EventType = string(header.Value)
+		}
+	}
+
+	return entry, nil
+}
+
+// originalPayload extrai o evento original do formato antigo. Os consumidores gravavam o payload
+// como string JSON; eventos gravados como objeto são usados diretamente.
+func originalPayload(raw json.RawMessage) []byte {
+	var text string
+	if err := json.Unmarshal(raw, &text); err == nil {
+		return []byte(text)
+	}
+	return raw
+}
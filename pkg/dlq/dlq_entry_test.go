@@ -0,0 +1,108 @@
+package dlq
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	pkgkafka "pkg/kafka"
+
+	"github.com/segmentio/kafka-go"
+)
+
+func TestParseEntry(t *testing.T) {
+	messageTime := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+	failedAt := time.Date(2024, 3, 10, 13, 0, 0, 0, time.UTC)
+
+	envelope, err := json.Marshal(pkgkafka.DLQEnvelope{
+		OriginalTopic:  "orders",
+		OriginalOffset: 15,
+		Key:            []byte{0x00, 0xff},
+		Value:          []byte(`{"id":42}`),
+		Headers:        []pkgkafka.DLQHeader{{Key: "event_type", Value: []byte("order.created")}},
+		ErrorMessage:   "falhou",
+		Attempts:       3,
+		FirstFailureAt: messageTime,
+		LastFailureAt:  failedAt,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name          string
+		message       kafka.Message
+		wantErr       bool
+		wantOriginal  string
+		wantEventType string
+		wantValue     string
+		wantAttempts  int
+		wantFailedAt  time.Time
+	}{
+		{
+			name:          "envelope completo com event_type no header original",
+			message:       kafka.Message{Topic: "orders.dlq", Value: envelope, Time: messageTime},
+			wantOriginal:  "orders",
+			wantEventType: "order.created",
+			wantValue:     `{"id":42}`,
+			wantAttempts:  3,
+			wantFailedAt:  failedAt,
+		},
+		{
+			name: "formato antigo com evento em string JSON",
+			message: kafka.Message{
+				Topic: "order.created.dlq",
+				Value: []byte(`{"original_topic":"order.created","error_message":"falhou","timestamp":"2024-03-10T13:00:00Z","original_event":"{\"id\":42}"}`),
+				Time:  messageTime,
+			},
+			wantOriginal:  "order.created",
+			wantEventType: "order.created",
+			wantValue:     `{"id":42}`,
+			wantAttempts:  1,
+			wantFailedAt:  failedAt,
+		},
+		{
+			name: "formato antigo com evento em objeto e sem tópico original",
+			message: kafka.Message{
+				Topic: "order.paid.dlq",
+				Value: []byte(`{"error_message":"falhou","original_event":{"id":42}}`),
+				Time:  messageTime,
+			},
+			wantOriginal:  "order.paid",
+			wantEventType: "order.paid",
+			wantValue:     `{"id":42}`,
+			wantAttempts:  1,
+			wantFailedAt:  messageTime,
+		},
+		{
+			name:    "formato inválido",
+			message: kafka.Message{Topic: "order.created.dlq", Value: []byte("não é json")},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry, err := parseEntry(tt.message)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseEntry() erro = %v, esperado erro = %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if entry.OriginalTopic != tt.wantOriginal || entry.EventType != tt.wantEventType {
+				t.Errorf("tópico original %q e event_type %q, esperado %q e %q",
+					entry.OriginalTopic, entry.EventType, tt.wantOriginal, tt.wantEventType)
+			}
+			if string(entry.Value) != tt.wantValue {
+				t.Errorf("valor %s, esperado %s", entry.Value, tt.wantValue)
+			}
+			if entry.Attempts != tt.wantAttempts {
+				t.Errorf("%d tentativas, esperado %d", entry.Attempts, tt.wantAttempts)
+			}
+			if !entry.LastFailureAt.Equal(tt.wantFailedAt) {
+				t.Errorf("última falha %s, esperado %s", entry.LastFailureAt, tt.wantFailedAt)
+			}
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package dlq
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Filter filtros de listagem e replay da DLQ (campos vazios são ignorados)
+type Filter struct {
+	Since         time.Time       // Última falha a partir deste instante
+	Until         time.Time       // Última falha até este instante
+	ErrorContains string          // Trecho do error_message (sem diferenciar maiúsculas)
+	EventType     string          // event_type exato
+	IDs           map[string]bool // Entradas selecionadas (partição:offset); vazio = todas
+}
+
+// Matches verifica se a entrada atende aos filtros
+func (f Filter) Matches(entry Entry) bool {
+	if !f.Since.IsZero() && entry.LastFailureAt.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && entry.LastFailureAt.After(f.Until) {
+		return false
+	}
+	if f.ErrorContains != "" && !strings.Contains(strings.ToLower(entry.ErrorMessage), strings.ToLower(f.ErrorContains)) {
+		return false
+	}
+	if f.EventType != "" && entry.EventType != f.EventType {
+		return false
+	}
+	if len(f.IDs) > 0 && !f.IDs[entry.ID()] {
+		return false
+	}
+	return true
+}
+
+// ParseIDs interpreta uma lista de entradas no formato "0:15,1:20" (partição:offset)
+func ParseIDs(spec string) (map[string]bool, error) {
+	ids := make(map[string]bool)
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		partitionText, offsetText, found := strings.Cut(entry, ":")
+		partition, partitionErr := strconv.Atoi(partitionText)
+		offset, offsetErr := strconv.ParseInt(offsetText, 10, 64)
+		if !found || partitionErr != nil || offsetErr != nil {
+			return nil, fmt.Errorf("entrada inválida: %q (esperado partição:offset)", entry)
+		}
+
+		ids[formatID(partition, offset)] = true
+	}
+	return ids, nil
+}
+
+// formatID formata o identificador partição:offset
+func formatID(partition int, offset int64) string {
+	return fmt.Sprintf("%d:%d", partition, offset)
+}
@@ -0,0 +1,84 @@
+package dlq
+
+import (
+	"testing"
+	"time"
+
+	pkgkafka "pkg/kafka"
+)
+
+func TestParseIDs(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    string
+		want    []string
+		wantErr bool
+	}{
+		{"vazio", "", nil, false},
+		{"uma entrada", "0:15", []string{"0:15"}, false},
+		{"várias entradas com espaços", "0:15, 1:20 ,", []string{"0:15", "1:20"}, false},
+		{"sem offset", "0", nil, true},
+		{"partição inválida", "a:15", nil, true},
+		{"offset inválido", "0:x", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ids, err := ParseIDs(tt.spec)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseIDs(%q) erro = %v, esperado erro = %v", tt.spec, err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if len(ids) != len(tt.want) {
+				t.Fatalf("ParseIDs(%q) = %v, esperado %v", tt.spec, ids, tt.want)
+			}
+			for _, id := range tt.want {
+				if !ids[id] {
+					t.Errorf("ParseIDs(%q) = %v, falta %s", tt.spec, ids, id)
+				}
+			}
+		})
+	}
+}
+
+func TestFilterMatches(t *testing.T) {
+	failedAt := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+	entry := Entry{
+		Partition: 1,
+		Offset:    20,
+		EventType: "order.created",
+		DLQEnvelope: pkgkafka.DLQEnvelope{
+			ErrorMessage:  "Timeout ao gravar no MongoDB",
+			LastFailureAt: failedAt,
+		},
+	}
+
+	tests := []struct {
+		name   string
+		filter Filter
+		want   bool
+	}{
+		{"sem filtros", Filter{}, true},
+		{"depois de since", Filter{Since: failedAt.Add(-time.Hour)}, true},
+		{"antes de since", Filter{Since: failedAt.Add(time.Hour)}, false},
+		{"antes de until", Filter{Until: failedAt.Add(time.Hour)}, true},
+		{"depois de until", Filter{Until: failedAt.Add(-time.Hour)}, false},
+		{"erro sem diferenciar maiúsculas", Filter{ErrorContains: "mongodb"}, true},
+		{"erro diferente", Filter{ErrorContains: "mysql"}, false},
+		{"mesmo event_type", Filter{EventType: "order.created"}, true},
+		{"outro event_type", Filter{EventType: "order.paid"}, false},
+		{"id selecionado", Filter{IDs: map[string]bool{"1:20": true}}, true},
+		{"id não selecionado", Filter{IDs: map[string]bool{"0:20": true}}, false},
+		{"todos os filtros", Filter{Since: failedAt.Add(-time.Hour), ErrorContains: "timeout", EventType: "order.created", IDs: map[string]bool{"1:20": true}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filter.Matches(entry); got != tt.want {
+				t.Errorf("Matches() = %v, esperado %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,178 @@
+package dlq
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/segmentio/kafka-go"
+)
+
+// HeaderReplayedFrom header adicionado às mensagens republicadas (<dlq>/<partição>/<offset>)
+const HeaderReplayedFrom = "replayed_from"
+
+// readIdleTimeout tempo sem novas mensagens após o qual a partição é considerada lida: os offsets
+// restantes até o high-water mark são marcadores de transação ou registros compactados
+const readIdleTimeout = 10 * time.Second
+
+// Publisher publica a mensagem original de volta no tópico de origem, sem alterar os headers
+type Publisher interface {
+	PublishWithHeaders(ctx context.Context, topic string, key, value []byte, headers []kafka.Header) error
+}
+
+// Replayer lista e republica mensagens de tópicos de DLQ
+type Replayer struct {
+	brokers   []string
+	publisher Publisher
+	journal   *Journal
+}
+
+// NewReplayer cria um novo replayer. O journal registra as entradas republicadas e evita que
+// sejam republicadas de novo; pode ser nil.
+func NewReplayer(brokers []string, publisher Publisher, journal *Journal) *Replayer {
+	return &Replayer{
+		brokers:   brokers,
+		publisher: publisher,
+		journal:   journal,
+	}
+}
+
+// List lê o tópico de DLQ do início até o fim atual e retorna as entradas que atendem ao filtro,
+// ordenadas pela data da última falha. Não usa consumer group, portanto não altera offsets.
+func (r *Replayer) List(ctx context.Context, topic string, filter Filter) ([]Entry, error) {
+	conn, err := kafka.DialContext(ctx, "tcp", r.brokers[0])
+	if err != nil {
+		return nil, fmt.Errorf("erro ao conectar ao Kafka: %w", err)
+	}
+	partitions, err := conn.ReadPartitions(topic)
+	conn.Close()
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler partições do tópico %s: %w", topic, err)
+	}
+
+	var entries []Entry
+	for _, partition := range partitions {
+		partitionEntries, err := r.readPartition(ctx, topic, partition.ID, filter)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, partitionEntries...)
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].LastFailureAt.Before(entries[j].LastFailureAt)
+	})
+	return entries, nil
+}
+
+// readPartition lê uma partição do primeiro offset até o high-water mark capturado no início da
+// leitura. Como nem todo offset corresponde a uma mensagem (compactação e marcadores de transação),
+// a leitura também termina quando o reader não tem atraso ou quando nenhuma mensagem chega em
+// readIdleTimeout.
+func (r *Replayer) readPartition(ctx context.Context, topic string, partition int, filter Filter) ([]Entry, error) {
+	leader, err := kafka.DialLeader(ctx, "tcp", r.brokers[0], topic, partition)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao conectar ao líder da partição %d: %w", partition, err)
+	}
+	first, last, err := leader.ReadOffsets()
+	leader.Close()
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler offsets da partição %d: %w", partition, err)
+	}
+	if first >= last {
+		return nil, nil
+	}
+
+	reader := kafka.NewReader(kafka.ReaderConfig{
+		Brokers:   r.brokers,
+		Topic:     topic,
+		Partition: partition,
+		MinBytes:  1,
+		MaxBytes:  10e6, // 10MB
+		MaxWait:   time.Second,
+	})
+	defer reader.Close()
+
+	if err := reader.SetOffset(first); err != nil {
+		return nil, err
+	}
+
+	var entries []Entry
+	for {
+		readCtx, cancel := context.WithTimeout(ctx, readIdleTimeout)
+		message, err := reader.ReadMessage(readCtx)
+		cancel()
+		if err != nil {
+			if errors.Is(err, context.DeadlineExceeded) && ctx.Err() == nil {
+				return entries, nil
+			}
+			return nil, fmt.Errorf("erro ao ler partição %d: %w", partition, err)
+		}
+
+		entry, err := parseEntry(message)
+		if err != nil {
+			log.Warn().
+				Err(err).
+				Int("partition", message.Partition).
+				Int64("offset", message.Offset).
+				Msg("mensagem da DLQ em formato inválido ignorada")
+		} else if filter.Matches(entry) {
+			entries = append(entries, entry)
+		}
+
+		if message.Offset+1 >= last || reader.Lag() == 0 {
+			return entries, nil
+		}
+	}
+}
+
+// ReplayResult resultado do replay de uma entrada
+type ReplayResult struct {
+	Entry   Entry
+	Skipped bool  // Já republicada anteriormente (registrada no journal)
+	Err     error // Erro ao republicar
+}
+
+// Replay republica as entradas nos tópicos originais, com a chave e os headers originais.
+// Em dryRun nada é publicado nem registrado. Entradas já registradas no journal são puladas,
+// a menos que force seja verdadeiro.
+func (r *Replayer) Replay(ctx context.Context, entries []Entry, dryRun, force bool) []ReplayResult {
+	results := make([]ReplayResult, 0, len(entries))
+	for _, entry := range entries {
+		result := ReplayResult{Entry: entry}
+
+		switch {
+		case !force && r.journal != nil && r.journal.Contains(entry):
+			result.Skipped = true
+		case dryRun:
+		default:
+			result.Err = r.replay(ctx, entry)
+		}
+
+		results = append(results, result)
+	}
+	return results
+}
+
+// replay republica uma entrada exatamente como a mensagem original (chave, valor e headers),
+// acrescentando apenas o header replayed_from, e a registra no journal
+func (r *Replayer) replay(ctx context.Context, entry Entry) error {
+	headers := append(entry.ToKafkaHeaders(), kafka.Header{
+		Key:   HeaderReplayedFrom,
+		Value: []byte(fmt.Sprintf("%s/%d/%d", entry.Topic, entry.Partition, entry.Offset)),
+	})
+
+	if err := r.publisher.PublishWithHeaders(ctx, entry.OriginalTopic, entry.Key, entry.Value, headers); err != nil {
+		return err
+	}
+
+	if r.journal != nil {
+		if err := r.journal.Record(entry, time.Now()); err != nil {
+			return fmt.Errorf("mensagem republicada, mas não registrada: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,151 @@
+package dlq
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	pkgkafka "pkg/kafka"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// publishedMessage mensagem republicada pelo fakePublisher
+type publishedMessage struct {
+	topic   string
+	key     []byte
+	value   []byte
+	headers []kafka.Header
+}
+
+// fakePublisher registra as mensagens republicadas
+type fakePublisher struct {
+	published []publishedMessage
+	err       error
+}
+
+func (p *fakePublisher) PublishWithHeaders(ctx context.Context, topic string, key, value []byte, headers []kafka.Header) error {
+	if p.err != nil {
+		return p.err
+	}
+	p.published = append(p.published, publishedMessage{topic: topic, key: key, value: value, headers: headers})
+	return nil
+}
+
+// testEntry entrada de DLQ com chave binária e headers originais repetidos
+func testEntry(offset int64) Entry {
+	return Entry{
+		Topic:     "order.created.dlq",
+		Partition: 0,
+		Offset:    offset,
+		EventType: "order.created",
+		DLQEnvelope: pkgkafka.DLQEnvelope{
+			OriginalTopic: "order.created",
+			Key:           []byte{0x00, 0xff, 'k'},
+			Value:         []byte(`{"id":42}`),
+			Headers: []pkgkafka.DLQHeader{
+				{Key: "correlation_id", Value: []byte("abc")},
+				{Key: "tag", Value: []byte("a")},
+				{Key: "tag", Value: []byte("b")},
+			},
+			ErrorMessage: "falhou",
+			Attempts:     3,
+		},
+	}
+}
+
+func TestReplay(t *testing.T) {
+	publishErr := errors.New("broker indisponível")
+
+	tests := []struct {
+		name          string
+		journaled     []Entry // Entradas já republicadas anteriormente
+		dryRun        bool
+		force         bool
+		publishErr    error
+		wantPublished int
+		wantSkipped   int
+		wantErrors    int
+		wantJournaled bool // Se a entrada 7 fica registrada no journal
+	}{
+		{"republica e registra", nil, false, false, nil, 1, 0, 0, true},
+		{"dry run não publica nem registra", nil, true, false, nil, 0, 0, 0, false},
+		{"pula entrada já republicada", []Entry{testEntry(7)}, false, false, nil, 0, 1, 0, true},
+		{"force republica novamente", []Entry{testEntry(7)}, false, true, nil, 1, 0, 0, true},
+		{"erro ao publicar não registra", nil, false, false, publishErr, 0, 0, 1, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "replays.ndjson")
+			journal, err := OpenJournal(path)
+			if err != nil {
+				t.Fatalf("OpenJournal: %v", err)
+			}
+			for _, entry := range tt.journaled {
+				if err := journal.Record(entry, entry.LastFailureAt); err != nil {
+					t.Fatalf("Record: %v", err)
+				}
+			}
+
+			publisher := &fakePublisher{err: tt.publishErr}
+			results := NewReplayer(nil, publisher, journal).Replay(context.Background(), []Entry{testEntry(7)}, tt.dryRun, tt.force)
+
+			var skipped, failed int
+			for _, result := range results {
+				if result.Skipped {
+					skipped++
+				}
+				if result.Err != nil {
+					failed++
+				}
+			}
+			if len(publisher.published) != tt.wantPublished || skipped != tt.wantSkipped || failed != tt.wantErrors {
+				t.Errorf("%d publicadas, %d puladas, %d erros; esperado %d, %d, %d",
+					len(publisher.published), skipped, failed, tt.wantPublished, tt.wantSkipped, tt.wantErrors)
+			}
+
+			// O journal é relido do arquivo, como em uma nova execução do comando
+			reopened, err := OpenJournal(path)
+			if err != nil {
+				t.Fatalf("OpenJournal: %v", err)
+			}
+			if got := reopened.Contains(testEntry(7)); got != tt.wantJournaled {
+				t.Errorf("Contains() = %v, esperado %v", got, tt.wantJournaled)
+			}
+			if reopened.Contains(testEntry(8)) {
+				t.Error("Contains() = true para entrada nunca republicada")
+			}
+		})
+	}
+}
+
+func TestReplayRestoresOriginalMessage(t *testing.T) {
+	publisher := &fakePublisher{}
+	results := NewReplayer(nil, publisher, nil).Replay(context.Background(), []Entry{testEntry(7)}, false, false)
+	if results[0].Err != nil {
+		t.Fatalf("Replay: %v", results[0].Err)
+	}
+
+	message := publisher.published[0]
+	if message.topic != "order.created" || string(message.key) != "\x00\xffk" || string(message.value) != `{"id":42}` {
+		t.Errorf("republicada em %s com chave %q e valor %s", message.topic, message.key, message.value)
+	}
+
+	// Headers originais na mesma ordem, com repetições, seguidos da origem do replay
+	want := []kafka.Header{
+		{Key: "correlation_id", Value: []byte("abc")},
+		{Key: "tag", Value: []byte("a")},
+		{Key: "tag", Value: []byte("b")},
+		{Key: HeaderReplayedFrom, Value: []byte("order.created.dlq/0/7")},
+	}
+	if len(message.headers) != len(want) {
+		t.Fatalf("headers %v, esperado %v", message.headers, want)
+	}
+	for i, header := range message.headers {
+		if header.Key != want[i].Key || string(header.Value) != string(want[i].Value) {
+			t.Errorf("header %d = %s:%s, esperado %s:%s", i, header.Key, header.Value, want[i].Key, want[i].Value)
+		}
+	}
+}
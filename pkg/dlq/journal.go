@@ -0,0 +1,94 @@
+package dlq
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// journalRecord linha do arquivo NDJSON de replays
+type journalRecord struct {
+	Topic         string    `json:"topic"`
+	Partition     int       `json:"partition"`
+	Offset        int64     `json:"offset"`
+	OriginalTopic string    `json:"original_topic"`
+	EventType     string    `json:"event_type,omitempty"`
+	ErrorMessage  string    `json:"error_message"`
+	Attempts      int       `json:"attempts"`
+	ReplayedAt    time.Time `json:"replayed_at"`
+}
+
+// Journal registro em NDJSON das entradas já republicadas
+type Journal struct {
+	path     string
+	replayed map[string]bool // <tópico>/<partição>:<offset>
+}
+
+// OpenJournal carrega o registro de replays do arquivo informado (inexistente = vazio)
+func OpenJournal(path string) (*Journal, error) {
+	journal := &Journal{path: path, replayed: make(map[string]bool)}
+
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return journal, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao abrir registro de replays: %w", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var record journalRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return nil, fmt.Errorf("linha inválida no registro de replays: %w", err)
+		}
+		journal.replayed[journalKey(record.Topic, formatID(record.Partition, record.Offset))] = true
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("erro ao ler registro de replays: %w", err)
+	}
+
+	return journal, nil
+}
+
+// Contains verifica se a entrada já foi republicada
+func (j *Journal) Contains(entry Entry) bool {
+	return j.replayed[journalKey(entry.Topic, entry.ID())]
+}
+
+// Record acrescenta a entrada ao registro e força a escrita em disco
+func (j *Journal) Record(entry Entry, replayedAt time.Time) error {
+	file, err := os.OpenFile(j.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if err := json.NewEncoder(file).Encode(journalRecord{
+		Topic:         entry.Topic,
+		Partition:     entry.Partition,
+		Offset:        entry.Offset,
+		OriginalTopic: entry.OriginalTopic,
+		EventType:     entry.EventType,
+		ErrorMessage:  entry.ErrorMessage,
+		Attempts:      entry.Attempts,
+		ReplayedAt:    replayedAt,
+	}); err != nil {
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		return err
+	}
+
+	j.replayed[journalKey(entry.Topic, entry.ID())] = true
+	return nil
+}
+
+// journalKey chave de uma entrada no registro
+func journalKey(topic, id string) string {
+	return topic + "/" + id
+}
@@ -0,0 +1,86 @@
+package events
+
+import (
+	"context"
+)
+
+// Nomes dos headers de metadados propagados da outbox até os consumidores
+const (
+	HeaderCorrelationID = "correlation_id"
+	HeaderCausationID   = "causation_id"
+	HeaderTenant        = "tenant"
+	HeaderEventType     = "event_type"
+	HeaderSchemaVersion = "schema_version"
+	HeaderAggregateID   = "aggregate_id"
+)
+
+// Metadata metadados de um evento, transportados como headers da mensagem
+type Metadata struct {
+	CorrelationID string
+	CausationID   string
+	Tenant        string
+	EventType     string
+	SchemaVersion string
+	AggregateID   string
+	Extra         map[string]string // Headers adicionais livres
+}
+
+// ToHeaders converte os metadados em headers, omitindo campos vazios
+func (m Metadata) ToHeaders() map[string]string {
+	headers := make(map[string]string, len(m.Extra)+6)
+	for key, value := range m.Extra {
+		headers[key] = value
+	}
+
+	set := func(key, value string) {
+		if value != "" {
+			headers[key] = value
+		}
+	}
+	set(HeaderCorrelationID, m.CorrelationID)
+	set(HeaderCausationID, m.CausationID)
+	set(HeaderTenant, m.Tenant)
+	set(HeaderEventType, m.EventType)
+	set(HeaderSchemaVersion, m.SchemaVersion)
+	set(HeaderAggregateID, m.AggregateID)
+
+	return headers
+}
+
+// MetadataFromHeaders reconstrói os metadados a partir dos headers recebidos
+func MetadataFromHeaders(headers map[string]string) Metadata {
+	metadata := Metadata{Extra: make(map[string]string)}
+	for key, value := range headers {
+		switch key {
+		case HeaderCorrelationID:
+			metadata.CorrelationID = value
+		case HeaderCausationID:
+			metadata.CausationID = value
+		case HeaderTenant:
+			metadata.Tenant = value
+		case HeaderEventType:
+			metadata.EventType = value
+		case HeaderSchemaVersion:
+			metadata.SchemaVersion = value
+		case HeaderAggregateID:
+			metadata.AggregateID = value
+		default:
+			metadata.Extra[key] = value
+		}
+	}
+	return metadata
+}
+
+// metadataContextKey chave dos metadados no context.Context
+type metadataContextKey struct{}
+
+// ContextWithMetadata retorna um contexto carregando os metadados informados
+func ContextWithMetadata(ctx context.Context, metadata Metadata) context.Context {
+	return context.WithValue(ctx, metadataContextKey{}, metadata)
+}
+
+// MetadataFromContext retorna os metadados presentes no contexto, se houver
+func MetadataFromContext(ctx context.Context) (Metadata, bool) {
+	metadata, ok := ctx.Value(metadataContextKey{}).(Metadata)
+	return metadata, ok
+}
@@ -0,0 +1,11 @@
+package http
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Metrics expõe as métricas Prometheus do processo
+func Metrics() gin.HandlerFunc {
+	return gin.WrapH(promhttp.Handler())
+}
@@ -1,12 +1,19 @@
 package http
 
 import (
+	"crypto/subtle"
+	"strings"
 	"time"
+	pkgevents "pkg/events"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"github.com/rs/zerolog/log"
 )
 
+// CorrelationIDHeader header HTTP usado para propagar o correlation ID
+const CorrelationIDHeader = "X-Correlation-ID"
+
 // Logger middleware para logging de requisições
 func Logger() gin.HandlerFunc {
 	return gin.LoggerWithFormatter(func(param gin.LogFormatterParams) string {
@@ -38,6 +45,49 @@ func Recovery() gin.HandlerFunc {
 	})
 }
 
+// CorrelationID middleware que lê (ou gera) o correlation ID da requisição e o coloca no
+// contexto, de onde é propagado para os headers dos eventos gravados na outbox
+func CorrelationID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		correlationID := c.GetHeader(CorrelationIDHeader)
+		if correlationID == "" {
+			correlationID = uuid.NewString()
+		}
+		
+		c.Header(CorrelationIDHeader, correlationID)
+		ctx := pkgevents.ContextWithMetadata(c.Request.Context(), pkgevents.Metadata{CorrelationID: correlationID})
+		c.Request = c.Request.WithContext(ctx)
+		
+		c.Next()
+	}
+}
+
+// AdminAuth middleware que protege as rotas administrativas com o token informado, enviado no
+// header "Authorization: Bearer <token>". Sem token configurado as rotas ficam desabilitadas.
+func AdminAuth(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if token == "" {
+			c.AbortWithStatusJSON(403, ErrorResponse{
+				Error:   "Admin routes disabled",
+				Message: "defina ADMIN_TOKEN para habilitar as rotas administrativas",
+			})
+			return
+		}
+		
+		provided, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+			log.Warn().
+				Str("path", c.Request.URL.Path).
+				Str("client_ip", c.ClientIP()).
+				Msg("acesso administrativo negado")
+			c.AbortWithStatusJSON(401, ErrorResponse{Error: "Unauthorized"})
+			return
+		}
+		
+		c.Next()
+	}
+}
+
 // HealthCheck handler para healthcheck
 func HealthCheck() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -14,9 +14,11 @@ func SetupRouter(r *gin.Engine) {
 	// Middlewares globais
 	r.Use(Logger())
 	r.Use(Recovery())
+	r.Use(CorrelationID())
 	
-	// Health check
+	// Health check e métricas
 	r.GET("/healthz", HealthCheck())
+	r.GET("/metrics", Metrics())
 }
 
 // HomeHandler retorna informações sobre os endpoints disponíveis
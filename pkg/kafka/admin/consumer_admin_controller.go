@@ -0,0 +1,35 @@
+package admin
+
+import (
+	"net/http"
+	pkgkafka "pkg/kafka"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ConsumerAdminController controller para inspeção e pausa manual do consumo
+type ConsumerAdminController struct {
+	breaker *pkgkafka.CircuitBreaker
+}
+
+// NewConsumerAdminController cria um novo controller administrativo do consumidor
+func NewConsumerAdminController(breaker *pkgkafka.CircuitBreaker) *ConsumerAdminController {
+	return &ConsumerAdminController{breaker: breaker}
+}
+
+// GetStatus retorna o estado do circuit breaker e se o consumo está pausado
+func (c *ConsumerAdminController) GetStatus(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, gin.H{"data": c.breaker.Status()})
+}
+
+// Pause suspende a leitura de mensagens até Resume (offsets não avançam)
+func (c *ConsumerAdminController) Pause(ctx *gin.Context) {
+	c.breaker.Pause()
+	ctx.JSON(http.StatusOK, gin.H{"message": "Consumo pausado", "data": c.breaker.Status()})
+}
+
+// Resume retoma o consumo e fecha o circuito, se estiver aberto
+func (c *ConsumerAdminController) Resume(ctx *gin.Context) {
+	c.breaker.Resume()
+	ctx.JSON(http.StatusOK, gin.H{"message": "Consumo retomado", "data": c.breaker.Status()})
+}
@@ -0,0 +1,15 @@
+package admin
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+// SetupRoutes monta as rotas administrativas do consumidor em /admin/consumer
+func SetupRoutes(r gin.IRouter, controller *ConsumerAdminController) {
+	consumer := r.Group("/admin/consumer")
+	{
+		consumer.GET("/status", controller.GetStatus) // Estado do circuito e da pausa manual
+		consumer.POST("/pause", controller.Pause)     // Pausar a leitura (sem commit)
+		consumer.POST("/resume", controller.Resume)   // Retomar e fechar o circuito
+	}
+}
@@ -0,0 +1,140 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/segmentio/kafka-go"
+)
+
+// Writer publica mensagens no broker (satisfeito por *kafka.Writer)
+type Writer interface {
+	WriteMessages(ctx context.Context, messages ...kafka.Message) error
+	Close() error
+}
+
+// Reader lê mensagens como membro de um consumer group (satisfeito por *kafka.Reader)
+type Reader interface {
+	FetchMessage(ctx context.Context) (kafka.Message, error)
+	CommitMessages(ctx context.Context, messages ...kafka.Message) error
+	Close() error
+}
+
+// Broker transporte usado pelo Producer e pelo Router. O Kafka é o padrão; outras
+// implementações (ex: pkg/broker/memory) permitem rodar os fluxos sem containers.
+type Broker interface {
+	NewWriter() Writer
+	NewGroupReader(topics []string, groupID string) Reader
+}
+
+// ProducerConfig confiabilidade, compressão e agrupamento das mensagens publicadas pelo Producer.
+// A escrita é sempre síncrona: o Producer só retorna depois da confirmação do broker, da qual
+// dependem a outbox e o encaminhamento para retry e DLQ.
+type ProducerConfig struct {
+	RequiredAcks kafka.RequiredAcks // RequireAll: confirmada por todas as réplicas em sincronia
+	Compression  kafka.Compression  // 0 = sem compressão
+	BatchSize    int                // Mensagens por lote e partição
+	BatchTimeout time.Duration      // Espera máxima para completar um lote
+	MaxAttempts  int                // Tentativas de entrega de cada lote
+	WriteTimeout time.Duration      // Prazo de cada escrita no broker
+}
+
+// DefaultProducerConfig configuração padrão: durável (acks=all), sem compressão e com espera curta
+// de lote, para que publicações isoladas não aguardem o BatchTimeout padrão do kafka-go (1s)
+func DefaultProducerConfig() ProducerConfig {
+	return ProducerConfig{
+		RequiredAcks: kafka.RequireAll,
+		BatchSize:    100,
+		BatchTimeout: 10 * time.Millisecond,
+		MaxAttempts:  10,
+		WriteTimeout: 10 * time.Second,
+	}
+}
+
+// ParseRequiredAcks interpreta o nível de confirmação: all (ou -1), one (ou 1) e none (ou 0)
+func ParseRequiredAcks(value string) (kafka.RequiredAcks, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "all", "-1":
+		return kafka.RequireAll, nil
+	case "one", "1":
+		return kafka.RequireOne, nil
+	case "none", "0":
+		return kafka.RequireNone, nil
+	default:
+		return 0, fmt.Errorf("acks inválido: %q (use all, one ou none)", value)
+	}
+}
+
+// ParseCompression interpreta o codec de compressão: none (ou vazio), gzip, snappy, lz4 e zstd
+func ParseCompression(value string) (kafka.Compression, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "", "none":
+		return 0, nil
+	case "gzip":
+		return kafka.Gzip, nil
+	case "snappy":
+		return kafka.Snappy, nil
+	case "lz4":
+		return kafka.Lz4, nil
+	case "zstd":
+		return kafka.Zstd, nil
+	default:
+		return 0, fmt.Errorf("compressão inválida: %q (use none, gzip, snappy, lz4 ou zstd)", value)
+	}
+}
+
+// KafkaBroker broker Kafka real, acessado via segmentio/kafka-go
+type KafkaBroker struct {
+	brokers  []string
+	producer ProducerConfig
+}
+
+// NewKafkaBroker cria o transporte para os brokers Kafka informados, com a configuração padrão
+// do Producer
+func NewKafkaBroker(brokers []string) *KafkaBroker {
+	return NewKafkaBrokerWithConfig(brokers, DefaultProducerConfig())
+}
+
+// NewKafkaBrokerWithConfig cria o transporte com a configuração de Producer informada
+func NewKafkaBrokerWithConfig(brokers []string, producer ProducerConfig) *KafkaBroker {
+	return &KafkaBroker{brokers: brokers, producer: producer}
+}
+
+// NewWriter cria o writer síncrono usado pelo Producer
+func (b *KafkaBroker) NewWriter() Writer {
+	return &kafka.Writer{
+		Addr:         kafka.TCP(b.brokers...),
+		Balancer:     &kafka.Hash{}, // Mesma chave -> mesma partição (ordem por entidade)
+		RequiredAcks: b.producer.RequiredAcks,
+		Compression:  b.producer.Compression,
+		BatchSize:    b.producer.BatchSize,
+		BatchTimeout: b.producer.BatchTimeout,
+		MaxAttempts:  b.producer.MaxAttempts,
+		WriteTimeout: b.producer.WriteTimeout,
+		Async:        false, // Síncrono para garantir entrega
+		Logger:       kafka.LoggerFunc(log.Printf),
+	}
+}
+
+// NewGroupReader cria um reader do consumer group para os tópicos informados
+func (b *KafkaBroker) NewGroupReader(topics []string, groupID string) Reader {
+	return kafka.NewReader(kafka.ReaderConfig{
+		Brokers:     b.brokers,
+		GroupTopics: topics,
+		GroupID:     groupID,
+		MinBytes:    10e3, // 10KB
+		MaxBytes:    10e6, // 10MB
+		// Com CommitInterval, CommitMessages apenas registra o offset e o reader o envia
+		// periodicamente em lote (e uma última vez no Close)
+		CommitInterval: commitInterval,
+		Logger:         kafka.LoggerFunc(log.Printf),
+	})
+}
+
+// Close não tem efeito: writers e readers fecham as próprias conexões
+func (b *KafkaBroker) Close() error {
+	return nil
+}
@@ -0,0 +1,273 @@
+package kafka
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// CircuitState estado do circuit breaker do consumidor
+type CircuitState string
+
+const (
+	CircuitClosed   CircuitState = "closed"    // Consumo normal
+	CircuitOpen     CircuitState = "open"      // Dependência indisponível: leitura suspensa
+	CircuitHalfOpen CircuitState = "half_open" // Uma mensagem de sondagem verifica a dependência
+)
+
+// CircuitBreakerConfig configuração do circuit breaker
+type CircuitBreakerConfig struct {
+	FailureThreshold int           // Falhas transitórias consecutivas que abrem o circuito (0 = só pausa manual)
+	OpenTimeout      time.Duration // Tempo aberto antes de cada sondagem
+	MaxProbes        int           // Sondagens com falha de uma mesma mensagem antes de ela seguir para o retry
+}
+
+// CircuitStatus situação atual do circuit breaker, exposta na API administrativa
+type CircuitStatus struct {
+	State               CircuitState `json:"state"`
+	Paused              bool         `json:"paused"`
+	ConsecutiveFailures int          `json:"consecutive_failures"`
+	OpenedAt            *time.Time   `json:"opened_at,omitempty"`
+	LastError           string       `json:"last_error,omitempty"`
+}
+
+// CircuitBreaker suspende o consumo quando uma dependência do handler (ex: MongoDB, MySQL) fica
+// indisponível. Após FailureThreshold falhas transitórias consecutivas o circuito abre: a leitura
+// para, nada é confirmado e as mensagens em processamento aguardam em vez de consumir os níveis de
+// retry. A cada OpenTimeout uma única mensagem é reprocessada como sondagem; se tiver sucesso, o
+// circuito fecha e o consumo é retomado. Uma mensagem que falha em MaxProbes sondagens segue para o
+// retry, para que uma mensagem defeituosa não seja sondada para sempre. Pause e Resume controlam o
+// consumo manualmente.
+type CircuitBreaker struct {
+	config CircuitBreakerConfig
+
+	mu        sync.Mutex
+	state     CircuitState
+	failures  int
+	paused    bool
+	probing   bool // Sondagem em andamento no estado half_open
+	openedAt  time.Time
+	lastError string
+	timer     *time.Timer
+	changed   chan struct{} // Fechado (e recriado) a cada mudança, para acordar quem aguarda
+}
+
+// NewCircuitBreaker cria um circuit breaker fechado
+func NewCircuitBreaker(config CircuitBreakerConfig) *CircuitBreaker {
+	if config.OpenTimeout <= 0 {
+		config.OpenTimeout = 30 * time.Second
+	}
+	if config.MaxProbes <= 0 {
+		config.MaxProbes = 3
+	}
+	return &CircuitBreaker{
+		config:  config,
+		state:   CircuitClosed,
+		changed: make(chan struct{}),
+	}
+}
+
+// Pause suspende o consumo até Resume, independentemente do estado do circuito
+func (cb *CircuitBreaker) Pause() {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	if !cb.paused {
+		cb.paused = true
+		log.Warn().Msg("consumo pausado manualmente")
+		cb.notifyLocked()
+	}
+}
+
+// Resume retoma o consumo pausado manualmente e fecha o circuito, se estiver aberto
+func (cb *CircuitBreaker) Resume() {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	cb.paused = false
+	if cb.state != CircuitClosed {
+		cb.closeLocked()
+	}
+	log.Info().Msg("consumo retomado manualmente")
+	cb.notifyLocked()
+}
+
+// Status retorna a situação atual do circuito
+func (cb *CircuitBreaker) Status() CircuitStatus {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	status := CircuitStatus{
+		State:               cb.state,
+		Paused:              cb.paused,
+		ConsecutiveFailures: cb.failures,
+		LastError:           cb.lastError,
+	}
+	if cb.state != CircuitClosed {
+		openedAt := cb.openedAt
+		status.OpenedAt = &openedAt
+	}
+	return status
+}
+
+// waitFetch aguarda enquanto o consumo estiver pausado ou o circuito aberto, para que o reader
+// não busque novas mensagens. Um circuit breaker nil nunca bloqueia.
+func (cb *CircuitBreaker) waitFetch(ctx context.Context) error {
+	if cb == nil {
+		return nil
+	}
+	for {
+		cb.mu.Lock()
+		if !cb.paused && cb.state != CircuitOpen {
+			cb.mu.Unlock()
+			return nil
+		}
+		changed := cb.changed
+		cb.mu.Unlock()
+
+		if err := cb.wait(ctx, changed); err != nil {
+			return err
+		}
+	}
+}
+
+// acquire aguarda a permissão para processar uma mensagem. Com o circuito meio aberto, apenas uma
+// mensagem por vez é liberada, como sondagem (probe = true).
+func (cb *CircuitBreaker) acquire(ctx context.Context) (bool, error) {
+	if cb == nil {
+		return false, nil
+	}
+	for {
+		cb.mu.Lock()
+		if !cb.paused {
+			switch {
+			case cb.state == CircuitClosed:
+				cb.mu.Unlock()
+				return false, nil
+			case cb.state == CircuitHalfOpen && !cb.probing:
+				cb.probing = true
+				cb.mu.Unlock()
+				return true, nil
+			}
+		}
+		changed := cb.changed
+		cb.mu.Unlock()
+
+		if err := cb.wait(ctx, changed); err != nil {
+			return false, err
+		}
+	}
+}
+
+// release registra o resultado do processamento. failed indica uma falha transitória (erros
+// Permanent não dizem nada sobre a dependência). Retorna true se a mensagem deve aguardar o
+// circuito fechar e ser processada novamente, em vez de seguir para o retry.
+func (cb *CircuitBreaker) release(probe, failed bool, cause error) bool {
+	if cb == nil {
+		return false
+	}
+
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	if probe {
+		cb.probing = false
+		defer cb.notifyLocked()
+	}
+
+	if !failed {
+		switch {
+		case probe && cb.state == CircuitHalfOpen:
+			log.Info().Msg("sondagem bem-sucedida; circuito fechado e consumo retomado")
+			cb.closeLocked()
+		case cb.state == CircuitClosed:
+			cb.failures = 0
+		}
+		return false
+	}
+
+	cb.lastError = cause.Error()
+	switch cb.state {
+	case CircuitClosed:
+		cb.failures++
+		if cb.config.FailureThreshold <= 0 || cb.failures < cb.config.FailureThreshold {
+			return false
+		}
+		log.Error().
+			Err(cause).
+			Int("failures", cb.failures).
+			Dur("open_timeout", cb.config.OpenTimeout).
+			Msg("falhas consecutivas no handler; circuito aberto e consumo suspenso")
+		cb.openLocked()
+		return true
+	case CircuitHalfOpen:
+		if probe {
+			log.Warn().Err(cause).Msg("sondagem falhou; circuito continua aberto")
+			cb.openLocked()
+		}
+		return true
+	default:
+		// Mensagem iniciada antes da abertura: também aguarda
+		return true
+	}
+}
+
+// probesExhausted indica se a mensagem já falhou em todas as sondagens permitidas e deve seguir
+// para o retry em vez de continuar aguardando o circuito fechar
+func (cb *CircuitBreaker) probesExhausted(failedProbes int) bool {
+	return cb != nil && failedProbes >= cb.config.MaxProbes
+}
+
+// openLocked abre o circuito e agenda a próxima sondagem
+func (cb *CircuitBreaker) openLocked() {
+	if cb.state == CircuitClosed {
+		cb.openedAt = time.Now()
+	}
+	cb.state = CircuitOpen
+	if cb.timer != nil {
+		cb.timer.Stop()
+	}
+	cb.timer = time.AfterFunc(cb.config.OpenTimeout, cb.halfOpen)
+	cb.notifyLocked()
+}
+
+// halfOpen libera uma mensagem de sondagem após o tempo aberto
+func (cb *CircuitBreaker) halfOpen() {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	if cb.state == CircuitOpen {
+		cb.state = CircuitHalfOpen
+		cb.notifyLocked()
+	}
+}
+
+// closeLocked fecha o circuito e zera as falhas
+func (cb *CircuitBreaker) closeLocked() {
+	if cb.timer != nil {
+		cb.timer.Stop()
+		cb.timer = nil
+	}
+	cb.state = CircuitClosed
+	cb.failures = 0
+	cb.lastError = ""
+	cb.notifyLocked()
+}
+
+// notifyLocked acorda quem aguarda uma mudança de estado
+func (cb *CircuitBreaker) notifyLocked() {
+	close(cb.changed)
+	cb.changed = make(chan struct{})
+}
+
+// wait aguarda uma mudança de estado ou o cancelamento do contexto
+func (cb *CircuitBreaker) wait(ctx context.Context, changed <-chan struct{}) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-changed:
+		return nil
+	}
+}
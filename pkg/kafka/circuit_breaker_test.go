@@ -0,0 +1,215 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// waitState aguarda o circuito chegar ao estado informado
+func waitState(t *testing.T, cb *CircuitBreaker, want CircuitState) {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for cb.Status().State != want {
+		if time.Now().After(deadline) {
+			t.Fatalf("estado %s, esperado %s", cb.Status().State, want)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestCircuitBreakerTransitions(t *testing.T) {
+	cause := errors.New("mongodb indisponível")
+
+	// outcome resultado de uma mensagem processada fora de sondagem
+	type outcome struct {
+		failed    bool
+		wantHold  bool
+		wantState CircuitState
+	}
+
+	tests := []struct {
+		name      string
+		threshold int
+		outcomes  []outcome
+	}{
+		{
+			name:      "abre após falhas consecutivas",
+			threshold: 3,
+			outcomes: []outcome{
+				{true, false, CircuitClosed},
+				{true, false, CircuitClosed},
+				{true, true, CircuitOpen},
+			},
+		},
+		{
+			name:      "sucesso zera as falhas",
+			threshold: 2,
+			outcomes: []outcome{
+				{true, false, CircuitClosed},
+				{false, false, CircuitClosed},
+				{true, false, CircuitClosed},
+				{true, true, CircuitOpen},
+			},
+		},
+		{
+			name:      "sem limite nunca abre",
+			threshold: 0,
+			outcomes: []outcome{
+				{true, false, CircuitClosed},
+				{true, false, CircuitClosed},
+				{true, false, CircuitClosed},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cb := NewCircuitBreaker(CircuitBreakerConfig{FailureThreshold: tt.threshold, OpenTimeout: time.Hour})
+			defer cb.Resume()
+
+			for i, o := range tt.outcomes {
+				probe, err := cb.acquire(context.Background())
+				if err != nil || probe {
+					t.Fatalf("acquire() = (%v, %v) com o circuito fechado", probe, err)
+				}
+				if hold := cb.release(false, o.failed, cause); hold != o.wantHold {
+					t.Errorf("mensagem %d: release() = %v, esperado %v", i, hold, o.wantHold)
+				}
+				if state := cb.Status().State; state != o.wantState {
+					t.Errorf("mensagem %d: estado %s, esperado %s", i, state, o.wantState)
+				}
+			}
+		})
+	}
+}
+
+func TestCircuitBreakerProbe(t *testing.T) {
+	cause := errors.New("mongodb indisponível")
+
+	tests := []struct {
+		name        string
+		probeFailed bool
+		wantHold    bool
+		wantState   CircuitState
+	}{
+		{"sondagem bem-sucedida fecha o circuito", false, false, CircuitClosed},
+		{"sondagem com falha reabre o circuito", true, true, CircuitOpen},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cb := NewCircuitBreaker(CircuitBreakerConfig{FailureThreshold: 1, OpenTimeout: 20 * time.Millisecond})
+			defer cb.Resume()
+
+			cb.acquire(context.Background())
+			cb.release(false, true, cause)
+			waitState(t, cb, CircuitHalfOpen)
+
+			probe, err := cb.acquire(context.Background())
+			if err != nil || !probe {
+				t.Fatalf("acquire() = (%v, %v), esperado sondagem", probe, err)
+			}
+
+			// Enquanto a sondagem não termina, nenhuma outra mensagem é liberada
+			ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+			if _, err := cb.acquire(ctx); !errors.Is(err, context.DeadlineExceeded) {
+				t.Errorf("segunda mensagem liberada durante a sondagem: %v", err)
+			}
+			cancel()
+
+			if hold := cb.release(true, tt.probeFailed, cause); hold != tt.wantHold {
+				t.Errorf("release() = %v, esperado %v", hold, tt.wantHold)
+			}
+			if state := cb.Status().State; state != tt.wantState {
+				t.Errorf("estado %s, esperado %s", state, tt.wantState)
+			}
+		})
+	}
+}
+
+func TestCircuitBreakerProbesExhausted(t *testing.T) {
+	tests := []struct {
+		name         string
+		maxProbes    int
+		failedProbes int
+		want         bool
+	}{
+		{"sem sondagens com falha", 2, 0, false},
+		{"abaixo do limite", 2, 1, false},
+		{"limite atingido", 2, 2, true},
+		{"limite padrão", 0, 3, true},
+		{"abaixo do limite padrão", 0, 2, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cb := NewCircuitBreaker(CircuitBreakerConfig{FailureThreshold: 1, MaxProbes: tt.maxProbes})
+			if got := cb.probesExhausted(tt.failedProbes); got != tt.want {
+				t.Errorf("probesExhausted(%d) = %v, esperado %v", tt.failedProbes, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCircuitBreakerBlocksFetchWhileOpenOrPaused(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(cb *CircuitBreaker)
+	}{
+		{"aberto", func(cb *CircuitBreaker) {
+			cb.acquire(context.Background())
+			cb.release(false, true, errors.New("mongodb indisponível"))
+		}},
+		{"pausado", func(cb *CircuitBreaker) { cb.Pause() }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cb := NewCircuitBreaker(CircuitBreakerConfig{FailureThreshold: 1, OpenTimeout: time.Hour})
+			tt.setup(cb)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+			defer cancel()
+			if err := cb.waitFetch(ctx); !errors.Is(err, context.DeadlineExceeded) {
+				t.Fatalf("waitFetch() = %v, esperado bloqueio", err)
+			}
+
+			// Resume libera a leitura e fecha o circuito
+			fetched := make(chan error, 1)
+			go func() { fetched <- cb.waitFetch(context.Background()) }()
+			cb.Resume()
+			select {
+			case err := <-fetched:
+				if err != nil {
+					t.Errorf("waitFetch() = %v", err)
+				}
+			case <-time.After(time.Second):
+				t.Fatal("waitFetch() não liberado após Resume")
+			}
+
+			status := cb.Status()
+			if status.State != CircuitClosed || status.Paused || status.ConsecutiveFailures != 0 {
+				t.Errorf("status após Resume = %+v", status)
+			}
+		})
+	}
+}
+
+func TestNilCircuitBreakerNeverBlocks(t *testing.T) {
+	var cb *CircuitBreaker
+	if err := cb.waitFetch(context.Background()); err != nil {
+		t.Errorf("waitFetch() = %v", err)
+	}
+	if probe, err := cb.acquire(context.Background()); probe || err != nil {
+		t.Errorf("acquire() = (%v, %v)", probe, err)
+	}
+	if cb.release(false, true, errors.New("falhou")) {
+		t.Error("release() = true com circuit breaker nil")
+	}
+	if cb.probesExhausted(10) {
+		t.Error("probesExhausted() = true com circuit breaker nil")
+	}
+}
@@ -3,121 +3,105 @@ package kafka
 import (
 	"context"
 	"encoding/json"
-	"fmt"
-	"math"
+	"hash/fnv"
 	"time"
-
-	"github.com/rs/zerolog/log"
-	"github.com/segmentio/kafka-go"
 )
 
-// MessageHandler função para processar mensagens
+// MessageHandler função para processar mensagens que recebe apenas o valor
 type MessageHandler func(ctx context.Context, message []byte) error
 
-// Consumer wrapper para o consumidor Kafka
+// Handler função para processar mensagens com acesso à chave, aos headers, ao tópico, à partição,
+// ao offset e ao timestamp
+type Handler func(ctx context.Context, message Message) error
+
+// AdaptMessageHandler adapta um MessageHandler para a assinatura Handler
+func AdaptMessageHandler(handler MessageHandler) Handler {
+	return func(ctx context.Context, message Message) error {
+		return handler(ctx, message.Value)
+	}
+}
+
+// commitInterval intervalo em que os offsets confirmados são enviados ao broker em lote
+const commitInterval = time.Second
+
+// workerQueueSize mensagens enfileiradas por worker antes de a leitura aguardar
+const workerQueueSize = 64
+
+// publishRetryBaseBackoff e publishRetryMaxBackoff limites do backoff ao encaminhar uma mensagem
+// com falha para o tópico de retry ou para a DLQ
+const (
+	publishRetryBaseBackoff = time.Second
+	publishRetryMaxBackoff  = 30 * time.Second
+)
+
+// Consumer consumidor de um único tópico. É um Router com uma só rota; para vários tópicos no
+// mesmo consumer group, use o Router diretamente.
 type Consumer struct {
-	reader *kafka.Reader
-	producer *Producer
-	maxRetries int
+	topic  string
+	router *Router
 }
 
-// NewConsumer cria um novo consumidor Kafka
+// NewConsumer cria um novo consumidor Kafka com os níveis de retry padrão
 func NewConsumer(brokers []string, topic, groupID string, producer *Producer) *Consumer {
-	reader := kafka.NewReader(kafka.ReaderConfig{
-		Brokers:  brokers,
-		Topic:    topic,
-		GroupID:  groupID,
-		MinBytes: 10e3, // 10KB
-		MaxBytes: 10e6, // 10MB
-		Logger:   kafka.LoggerFunc(log.Printf),
-	})
-	
+	return NewConsumerWithBroker(NewKafkaBroker(brokers), topic, groupID, producer)
+}
+
+// NewConsumerWithBroker cria um consumidor que lê do transporte informado (ex: broker em memória)
+func NewConsumerWithBroker(broker Broker, topic, groupID string, producer *Producer) *Consumer {
 	return &Consumer{
-		reader:     reader,
-		producer:   producer,
-		maxRetries: 5,
+		topic:  topic,
+		router: NewRouterWithBroker(broker, groupID, producer),
 	}
 }
 
-// Consume inicia o consumo de mensagens com retry e DLQ
+// SetRetryDelays define os atrasos dos tópicos de retry (ex: 5s, 1m). Sem atrasos, a mensagem
+// com falha vai direto para a DLQ.
+func (c *Consumer) SetRetryDelays(delays []time.Duration) {
+	c.router.SetRetryDelays(delays)
+}
+
+// SetWorkers define quantas mensagens são processadas em paralelo. A ordem é mantida por chave:
+// mensagens com a mesma chave são processadas pelo mesmo worker, na ordem em que foram lidas.
+func (c *Consumer) SetWorkers(workers int) {
+	c.router.SetWorkers(workers)
+}
+
+// SetCircuitBreaker define o circuit breaker do consumidor (ver Router.SetCircuitBreaker)
+func (c *Consumer) SetCircuitBreaker(breaker *CircuitBreaker) {
+	c.router.SetCircuitBreaker(breaker)
+}
+
+// Use adiciona middlewares aplicados ao handler (ver Router.Use)
+func (c *Consumer) Use(middlewares ...Middleware) {
+	c.router.Use(middlewares...)
+}
+
+// Consume inicia o consumo do tópico e dos seus tópicos de retry até o contexto ser cancelado
 func (c *Consumer) Consume(ctx context.Context, handler MessageHandler) error {
-	log.Info().
-		Str("topic", c.reader.Config().Topic).
-		Str("group_id", c.reader.Config().GroupID).
-		Msg("iniciando consumo de mensagens")
-	
-	for {
-		select {
-		case <-ctx.Done():
-			log.Info().Msg("consumo interrompido")
-			return ctx.Err()
-		default:
-			message, err := c.reader.ReadMessage(ctx)
-			if err != nil {
-				log.Error().Err(err).Msg("erro ao ler mensagem")
-				continue
-			}
-			
-			// Processa mensagem com retry
-			if err := c.processWithRetry(ctx, message, handler); err != nil {
-				log.Error().
-					Err(err).
-					Str("topic", message.Topic).
-					Int("partition", message.Partition).
-					Int64("offset", message.Offset).
-					Msg("falha ao processar mensagem após retries")
-				
-				// Publica na DLQ
-				if err := c.producer.PublishToDLQ(ctx, message.Topic, string(message.Value), err.Error()); err != nil {
-					log.Error().Err(err).Msg("erro ao publicar na DLQ")
-				}
-			}
-		}
-	}
+	c.router.Handle(c.topic, handler)
+	return c.router.Run(ctx)
 }
 
-// processWithRetry processa uma mensagem com retry exponencial
-func (c *Consumer) processWithRetry(ctx context.Context, message kafka.Message, handler MessageHandler) error {
-	var lastErr error
-	
-	for attempt := 0; attempt <= c.maxRetries; attempt++ {
-		if attempt > 0 {
-			// Backoff exponencial: 1s, 2s, 4s, 8s, 16s
-			backoff := time.Duration(math.Pow(2, float64(attempt-1))) * time.Second
-			log.Info().
-				Int("attempt", attempt).
-				Dur("backoff", backoff).
-				Msg("tentativa de retry")
-			
-			select {
-			case <-ctx.Done():
-				return ctx.Err()
-			case <-time.After(backoff):
-			}
-		}
-		
-		if err := handler(ctx, message.Value); err != nil {
-			lastErr = err
-			log.Error().
-				Err(err).
-				Int("attempt", attempt+1).
-				Msg("erro ao processar mensagem")
-			continue
-		}
-		
-		// Sucesso
-		log.Info().
-			Int("attempt", attempt+1).
-			Msg("mensagem processada com sucesso")
-		return nil
-	}
-	
-	return fmt.Errorf("falha após %d tentativas: %w", c.maxRetries+1, lastErr)
+// ConsumeMessages igual a Consume, com um handler que recebe a mensagem completa
+func (c *Consumer) ConsumeMessages(ctx context.Context, handler Handler) error {
+	c.router.HandleMessage(c.topic, handler)
+	return c.router.Run(ctx)
 }
 
-// Close fecha o consumidor
+// Close mantido por compatibilidade: os readers são fechados (e os offsets pendentes enviados)
+// ao final de Consume
 func (c *Consumer) Close() error {
-	return c.reader.Close()
+	return nil
+}
+
+// workerFor escolhe o worker pela chave, para que a mesma chave seja sempre processada em ordem
+func workerFor(key []byte, workers int) int {
+	if workers == 1 {
+		return 0
+	}
+	hash := fnv.New32a()
+	hash.Write(key)
+	return int(hash.Sum32() % uint32(workers))
 }
 
 // UnmarshalMessage deserializa uma mensagem JSON
@@ -0,0 +1,109 @@
+package kafka
+
+import (
+	"reflect"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// DLQHeader header Kafka preservado byte a byte (o valor é serializado em base64 no JSON)
+type DLQHeader struct {
+	Key   string `json:"key"`
+	Value []byte `json:"value"`
+}
+
+// DLQEnvelope mensagem gravada na DLQ. Carrega a mensagem original sem perdas (bytes da chave e
+// do valor, headers, partição, offset e timestamp) e o histórico de falhas, de modo que um
+// replay reproduz exatamente a mensagem original.
+type DLQEnvelope struct {
+	OriginalTopic     string      `json:"original_topic"`
+	OriginalPartition int         `json:"original_partition"`
+	OriginalOffset    int64       `json:"original_offset"`
+	OriginalTimestamp time.Time   `json:"original_timestamp"`
+	Key               []byte      `json:"key"`
+	Value             []byte      `json:"value"`
+	Headers           []DLQHeader `json:"headers,omitempty"`
+
+	ErrorMessage   string    `json:"error_message"`
+	Attempts       int       `json:"attempts"`
+	ConsumerGroup  string    `json:"consumer_group"`
+	Handler        string    `json:"handler"`
+	FirstFailureAt time.Time `json:"first_failure_at"`
+	LastFailureAt  time.Time `json:"last_failure_at"`
+}
+
+// DLQTopic retorna o nome do tópico de DLQ do consumer group para o tópico informado (ex:
+// order.created.product-consumer.dlq)
+func DLQTopic(topic, groupID string) string {
+	return topic + "." + groupID + ".dlq"
+}
+
+// ToKafkaHeaders converte os headers do envelope de volta em headers Kafka
+func (e DLQEnvelope) ToKafkaHeaders() []kafka.Header {
+	headers := make([]kafka.Header, len(e.Headers))
+	for i, header := range e.Headers {
+		headers[i] = kafka.Header{Key: header.Key, Value: header.Value}
+	}
+	return headers
+}
+
+// newDLQEnvelope monta o envelope a partir da mensagem que falhou no último nível de retry.
+// Nos tópicos de retry, os dados da mensagem original vêm dos headers de controle.
+func newDLQEnvelope(message kafka.Message, groupID, handler string, cause error, now time.Time) DLQEnvelope {
+	headers := HeadersToMap(message.Headers)
+
+	envelope := DLQEnvelope{
+		OriginalTopic:     message.Topic,
+		OriginalPartition: message.Partition,
+		OriginalOffset:    message.Offset,
+		OriginalTimestamp: message.Time,
+		Key:               message.Key,
+		Value:             message.Value,
+		ErrorMessage:      cause.Error(),
+		Attempts:          retryAttempt(headers) + 1,
+		ConsumerGroup:     groupID,
+		Handler:           handler,
+		FirstFailureAt:    now,
+		LastFailureAt:     now,
+	}
+
+	if topic := headers[HeaderOriginalTopic]; topic != "" {
+		envelope.OriginalTopic = topic
+	}
+	if partition, err := strconv.Atoi(headers[HeaderOriginalPartition]); err == nil {
+		envelope.OriginalPartition = partition
+	}
+	if offset, err := strconv.ParseInt(headers[HeaderOriginalOffset], 10, 64); err == nil {
+		envelope.OriginalOffset = offset
+	}
+	if timestamp, err := time.Parse(time.RFC3339Nano, headers[HeaderOriginalTimestamp]); err == nil {
+		envelope.OriginalTimestamp = timestamp
+	}
+	if firstFailure, err := time.Parse(time.RFC3339Nano, headers[HeaderFirstFailureAt]); err == nil {
+		envelope.FirstFailureAt = firstFailure
+	}
+
+	for _, header := range originalHeaders(message.Headers) {
+		envelope.Headers = append(envelope.Headers, DLQHeader{Key: header.Key, Value: header.Value})
+	}
+
+	return envelope
+}
+
+// handlerName retorna o nome da função do handler (ex: consumer.(*EventConsumer).HandleOrderPaid)
+func handlerName(handler interface{}) string {
+	fn := runtime.FuncForPC(reflect.ValueOf(handler).Pointer())
+	if fn == nil {
+		return "unknown"
+	}
+
+	name := strings.TrimSuffix(fn.Name(), "-fm")
+	if slash := strings.LastIndex(name, "/"); slash >= 0 {
+		name = name[slash+1:]
+	}
+	return name
+}
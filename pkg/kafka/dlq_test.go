@@ -0,0 +1,131 @@
+package kafka
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+func TestNewDLQEnvelope(t *testing.T) {
+	published := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+	firstFailure := published.Add(time.Minute)
+	now := published.Add(time.Hour)
+	cause := errors.New("estoque indisponível")
+
+	tests := []struct {
+		name    string
+		message kafka.Message
+		want    DLQEnvelope
+	}{
+		{
+			name: "falha no tópico principal",
+			message: kafka.Message{
+				Topic:     "order.created",
+				Partition: 2,
+				Offset:    15,
+				Time:      published,
+				Key:       []byte("order:42"),
+				Value:     []byte(`{"id":42}`),
+				Headers:   []kafka.Header{{Key: "correlation_id", Value: []byte("abc")}},
+			},
+			want: DLQEnvelope{
+				OriginalTopic:     "order.created",
+				OriginalPartition: 2,
+				OriginalOffset:    15,
+				OriginalTimestamp: published,
+				Key:               []byte("order:42"),
+				Value:             []byte(`{"id":42}`),
+				Headers:           []DLQHeader{{Key: "correlation_id", Value: []byte("abc")}},
+				ErrorMessage:      "estoque indisponível",
+				Attempts:          1,
+				ConsumerGroup:     "product-service",
+				Handler:           "handler",
+				FirstFailureAt:    now,
+				LastFailureAt:     now,
+			},
+		},
+		{
+			name: "falha no último nível de retry",
+			message: kafka.Message{
+				Topic:     "order.created.retry.1m",
+				Partition: 0,
+				Offset:    3,
+				Time:      now,
+				Key:       []byte("order:42"),
+				Value:     []byte(`{"id":42}`),
+				Headers: []kafka.Header{
+					{Key: "correlation_id", Value: []byte("abc")},
+					{Key: HeaderRetryAttempt, Value: []byte("2")},
+					{Key: HeaderLastError, Value: []byte("estoque indisponível")},
+					{Key: HeaderFirstFailureAt, Value: []byte(firstFailure.Format(time.RFC3339Nano))},
+					{Key: HeaderOriginalTopic, Value: []byte("order.created")},
+					{Key: HeaderOriginalPartition, Value: []byte("2")},
+					{Key: HeaderOriginalOffset, Value: []byte("15")},
+					{Key: HeaderOriginalTimestamp, Value: []byte(published.Format(time.RFC3339Nano))},
+				},
+			},
+			want: DLQEnvelope{
+				OriginalTopic:     "order.created",
+				OriginalPartition: 2,
+				OriginalOffset:    15,
+				OriginalTimestamp: published,
+				Key:               []byte("order:42"),
+				Value:             []byte(`{"id":42}`),
+				Headers:           []DLQHeader{{Key: "correlation_id", Value: []byte("abc")}},
+				ErrorMessage:      "estoque indisponível",
+				Attempts:          3,
+				ConsumerGroup:     "product-service",
+				Handler:           "handler",
+				FirstFailureAt:    firstFailure,
+				LastFailureAt:     now,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := newDLQEnvelope(tt.message, "product-service", "handler", cause, now)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("newDLQEnvelope() =\n%+v\nesperado\n%+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDLQEnvelopeRoundTrip(t *testing.T) {
+	envelope := DLQEnvelope{
+		OriginalTopic:     "order.created",
+		OriginalPartition: 1,
+		OriginalOffset:    7,
+		OriginalTimestamp: time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC),
+		Key:               []byte{0x00, 0xff, 'k'},
+		Value:             []byte{0xde, 0xad, 0xbe, 0xef},
+		Headers: []DLQHeader{
+			{Key: "tag", Value: []byte("a")},
+			{Key: "tag", Value: []byte{0x00}},
+		},
+		ErrorMessage: "falhou",
+		Attempts:     3,
+	}
+
+	data, err := json.Marshal(envelope)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded DLQEnvelope
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(decoded, envelope) {
+		t.Errorf("envelope decodificado\n%+v\nesperado\n%+v", decoded, envelope)
+	}
+
+	headers := decoded.ToKafkaHeaders()
+	if len(headers) != 2 || headers[0].Key != "tag" || string(headers[1].Value) != "\x00" {
+		t.Errorf("ToKafkaHeaders() = %v", headers)
+	}
+}
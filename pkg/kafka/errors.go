@@ -0,0 +1,67 @@
+package kafka
+
+import (
+	"errors"
+	"time"
+)
+
+// PermanentError falha que não se resolve com novas tentativas (ex: payload inválido). O Router
+// envia a mensagem direto para a DLQ, sem passar pelos tópicos de retry.
+type PermanentError struct {
+	Err error
+}
+
+func (e *PermanentError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *PermanentError) Unwrap() error {
+	return e.Err
+}
+
+// RetryableError falha transitória com um atraso sugerido antes da próxima tentativa (ex: limite
+// de taxa de uma API externa). Erros sem classificação também são tratados como transitórios.
+type RetryableError struct {
+	Err   error
+	After time.Duration
+}
+
+func (e *RetryableError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *RetryableError) Unwrap() error {
+	return e.Err
+}
+
+// Permanent marca o erro como permanente (nil continua nil)
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &PermanentError{Err: err}
+}
+
+// Retryable marca o erro como transitório, sugerindo aguardar after antes da próxima tentativa
+// (nil continua nil)
+func Retryable(err error, after time.Duration) error {
+	if err == nil {
+		return nil
+	}
+	return &RetryableError{Err: err, After: after}
+}
+
+// IsPermanent informa se o erro (ou algum erro encapsulado por ele) foi marcado como permanente
+func IsPermanent(err error) bool {
+	var permanent *PermanentError
+	return errors.As(err, &permanent)
+}
+
+// RetryAfter retorna o atraso sugerido por um erro marcado com Retryable
+func RetryAfter(err error) (time.Duration, bool) {
+	var retryable *RetryableError
+	if !errors.As(err, &retryable) || retryable.After <= 0 {
+		return 0, false
+	}
+	return retryable.After, true
+}
@@ -0,0 +1,59 @@
+package kafka
+
+import (
+	"fmt"
+	"time"
+	pkgevents "pkg/events"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// Message mensagem Kafka com chave, valor já serializado e headers. Na publicação, Partition,
+// Offset e Time são ignorados; no consumo, o Handler recebe a mensagem com todos os campos.
+type Message struct {
+	Topic     string
+	Partition int
+	Offset    int64
+	Key       []byte
+	Value     []byte
+	Headers   map[string]string
+	Time      time.Time
+}
+
+// Metadata retorna os metadados do evento (correlation_id, event_type, schema_version...) a partir
+// dos headers da mensagem
+func (m Message) Metadata() pkgevents.Metadata {
+	return pkgevents.MetadataFromHeaders(m.Headers)
+}
+
+// fromKafkaMessage converte uma mensagem lida do broker, com os headers já convertidos
+func fromKafkaMessage(message kafka.Message, headers map[string]string) Message {
+	return Message{
+		Topic:     message.Topic,
+		Partition: message.Partition,
+		Offset:    message.Offset,
+		Key:       message.Key,
+		Value:     message.Value,
+		Headers:   headers,
+		Time:      message.Time,
+	}
+}
+
+// BatchError erros individuais de uma publicação em lote, na mesma ordem das mensagens
+// enviadas. Posições nil indicam mensagens publicadas com sucesso.
+type BatchError []error
+
+// Error resume quantas mensagens do lote falharam
+func (e BatchError) Error() string {
+	failed := 0
+	var first error
+	for _, err := range e {
+		if err != nil {
+			failed++
+			if first == nil {
+				first = err
+			}
+		}
+	}
+	return fmt.Sprintf("%d de %d mensagens falharam: %v", failed, len(e), first)
+}
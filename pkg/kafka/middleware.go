@@ -0,0 +1,215 @@
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"runtime/debug"
+	"strings"
+	"time"
+	pkgevents "pkg/events"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/rs/zerolog/log"
+)
+
+// Middleware envolve um Handler com uma responsabilidade transversal (log, métricas,
+// idempotência...), sem que cada handler precise repeti-la
+type Middleware func(next Handler) Handler
+
+// Chain aplica os middlewares ao handler. O primeiro middleware é o mais externo: Chain(h, a, b)
+// executa a, depois b e por fim h.
+func Chain(handler Handler, middlewares ...Middleware) Handler {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		handler = middlewares[i](handler)
+	}
+	return handler
+}
+
+// Recovery converte um panic do handler em erro, para que a mensagem siga o fluxo de retry e DLQ
+// em vez de derrubar o consumidor
+func Recovery() Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, message Message) (err error) {
+			defer func() {
+				if recovered := recover(); recovered != nil {
+					log.Ctx(ctx).Error().
+						Str("topic", message.Topic).
+						Int("partition", message.Partition).
+						Int64("offset", message.Offset).
+						Str("stack", string(debug.Stack())).
+						Msgf("panic ao processar mensagem: %v", recovered)
+					err = fmt.Errorf("panic ao processar mensagem: %v", recovered)
+				}
+			}()
+			return next(ctx, message)
+		}
+	}
+}
+
+// Timeout limita o tempo de processamento de cada mensagem. O handler deve respeitar o
+// cancelamento do contexto recebido.
+func Timeout(timeout time.Duration) Middleware {
+	return func(next Handler) Handler {
+		if timeout <= 0 {
+			return next
+		}
+		return func(ctx context.Context, message Message) error {
+			ctx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+			return next(ctx, message)
+		}
+	}
+}
+
+// Logging registra o resultado de cada mensagem com tópico, partição, offset, tentativa e duração
+func Logging() Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, message Message) error {
+			start := time.Now()
+
+			err := next(ctx, message)
+
+			event := log.Ctx(ctx).Info()
+			msg := "mensagem processada com sucesso"
+			if err != nil {
+				event = log.Ctx(ctx).Error().Err(err)
+				msg = "erro ao processar mensagem"
+			}
+			event.
+				Str("topic", message.Topic).
+				Int("partition", message.Partition).
+				Int64("offset", message.Offset).
+				Int("attempt", retryAttempt(message.Headers)+1).
+				Dur("duration", time.Since(start)).
+				Msg(msg)
+			return err
+		}
+	}
+}
+
+// Métricas do consumo de mensagens
+var (
+	consumerMessagesTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "kafka_consumer_messages_total",
+		Help: "Mensagens processadas pelos consumidores, por tópico e resultado (success, error, permanent_error)",
+	}, []string{"topic", "result"})
+
+	consumerHandlerDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "kafka_consumer_handler_duration_seconds",
+		Help:    "Duração do processamento de uma mensagem pelo handler",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"topic"})
+)
+
+// Metrics exporta no Prometheus a contagem de mensagens por resultado e a duração do handler
+func Metrics() Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, message Message) error {
+			start := time.Now()
+
+			err := next(ctx, message)
+
+			result := "success"
+			switch {
+			case IsPermanent(err):
+				result = "permanent_error"
+			case err != nil:
+				result = "error"
+			}
+			consumerMessagesTotal.WithLabelValues(message.Topic, result).Inc()
+			consumerHandlerDuration.WithLabelValues(message.Topic).Observe(time.Since(start).Seconds())
+			return err
+		}
+	}
+}
+
+// HeaderTraceParent header W3C Trace Context propagado pelos produtores
+const HeaderTraceParent = "traceparent"
+
+// TraceContext identificadores de rastreamento extraídos do header traceparent
+type TraceContext struct {
+	TraceID string
+	SpanID  string
+	Sampled bool
+}
+
+// traceContextKey chave do TraceContext no context.Context
+type traceContextKey struct{}
+
+// TraceFromContext retorna o contexto de rastreamento da mensagem, se houver
+func TraceFromContext(ctx context.Context) (TraceContext, bool) {
+	trace, ok := ctx.Value(traceContextKey{}).(TraceContext)
+	return trace, ok
+}
+
+// parseTraceParent interpreta um traceparent no formato "00-<trace-id>-<span-id>-<flags>"
+func parseTraceParent(value string) (TraceContext, bool) {
+	parts := strings.Split(strings.TrimSpace(value), "-")
+	if len(parts) != 4 || len(parts[1]) != 32 || len(parts[2]) != 16 || len(parts[3]) != 2 {
+		return TraceContext{}, false
+	}
+	if strings.Trim(parts[1], "0") == "" || strings.Trim(parts[2], "0") == "" {
+		return TraceContext{}, false
+	}
+
+	return TraceContext{
+		TraceID: parts[1],
+		SpanID:  parts[2],
+		Sampled: parts[3] == "01",
+	}, true
+}
+
+// Tracing extrai o traceparent e o correlation_id dos headers e os disponibiliza no contexto,
+// inclusive no logger (log.Ctx), para que os logs do handler saiam correlacionados
+func Tracing() Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, message Message) error {
+			logger := log.Ctx(ctx).With()
+
+			if trace, ok := parseTraceParent(message.Headers[HeaderTraceParent]); ok {
+				ctx = context.WithValue(ctx, traceContextKey{}, trace)
+				logger = logger.Str("trace_id", trace.TraceID).Str("span_id", trace.SpanID)
+			}
+			if correlationID := message.Headers[pkgevents.HeaderCorrelationID]; correlationID != "" {
+				logger = logger.Str("correlation_id", correlationID)
+			}
+
+			ctx = logger.Logger().WithContext(ctx)
+			return next(ctx, message)
+		}
+	}
+}
+
+// IdempotencyStore controle de eventos já processados (ex: *idempotency.Handler)
+type IdempotencyStore interface {
+	ProcessWithIdempotency(ctx context.Context, eventID string, processor func() error) error
+}
+
+// Idempotency ignora eventos já processados, identificados pelo campo "event_id" do payload.
+// Mensagens sem event_id são processadas sem verificação.
+func Idempotency(store IdempotencyStore) Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, message Message) error {
+			eventID := eventIDOf(message.Value)
+			if eventID == "" {
+				return next(ctx, message)
+			}
+			return store.ProcessWithIdempotency(ctx, eventID, func() error {
+				return next(ctx, message)
+			})
+		}
+	}
+}
+
+// eventIDOf extrai o event_id do payload JSON, se houver
+func eventIDOf(message []byte) string {
+	var event struct {
+		EventID string `json:"event_id"`
+	}
+	if err := json.Unmarshal(message, &event); err != nil {
+		return ""
+	}
+	return event.EventID
+}
@@ -0,0 +1,159 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testMessage mensagem com o payload informado
+func testMessage(value string) Message {
+	return Message{Value: []byte(value)}
+}
+
+func TestChainOrder(t *testing.T) {
+	var calls []string
+	record := func(name string) Middleware {
+		return func(next Handler) Handler {
+			return func(ctx context.Context, message Message) error {
+				calls = append(calls, name+">")
+				err := next(ctx, message)
+				calls = append(calls, "<"+name)
+				return err
+			}
+		}
+	}
+	handler := func(ctx context.Context, message Message) error {
+		calls = append(calls, "handler")
+		return nil
+	}
+
+	if err := Chain(handler, record("a"), record("b"))(context.Background(), testMessage("{}")); err != nil {
+		t.Fatalf("Chain: %v", err)
+	}
+
+	want := "a> b> handler <b <a"
+	if got := strings.Join(calls, " "); got != want {
+		t.Errorf("ordem %q, esperado %q", got, want)
+	}
+}
+
+func TestRecovery(t *testing.T) {
+	handlerErr := errors.New("falhou")
+
+	tests := []struct {
+		name    string
+		handler Handler
+		wantErr string
+	}{
+		{"sucesso", func(ctx context.Context, message Message) error { return nil }, ""},
+		{"erro do handler", func(ctx context.Context, message Message) error { return handlerErr }, "falhou"},
+		{"panic vira erro", func(ctx context.Context, message Message) error { panic("nil map") }, "panic ao processar mensagem: nil map"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Chain(tt.handler, Recovery())(context.Background(), testMessage("{}"))
+			if got := errorString(err); got != tt.wantErr {
+				t.Errorf("erro %q, esperado %q", got, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	tests := []struct {
+		name         string
+		timeout      time.Duration
+		wantDeadline bool
+	}{
+		{"desativado", 0, false},
+		{"negativo desativa", -time.Second, false},
+		{"ativo", time.Minute, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := func(ctx context.Context, message Message) error {
+				if _, ok := ctx.Deadline(); ok != tt.wantDeadline {
+					t.Errorf("prazo no contexto = %v, esperado %v", ok, tt.wantDeadline)
+				}
+				return nil
+			}
+			if err := Chain(handler, Timeout(tt.timeout))(context.Background(), testMessage("{}")); err != nil {
+				t.Errorf("Timeout: %v", err)
+			}
+		})
+	}
+
+	t.Run("cancela o handler lento", func(t *testing.T) {
+		handler := func(ctx context.Context, message Message) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}
+		err := Chain(handler, Timeout(10*time.Millisecond))(context.Background(), testMessage("{}"))
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("erro %v, esperado %v", err, context.DeadlineExceeded)
+		}
+	})
+}
+
+// fakeIdempotencyStore executa cada event_id uma única vez
+type fakeIdempotencyStore struct {
+	processed map[string]bool
+}
+
+func (s *fakeIdempotencyStore) ProcessWithIdempotency(ctx context.Context, eventID string, processor func() error) error {
+	if s.processed[eventID] {
+		return nil
+	}
+	if err := processor(); err != nil {
+		return err
+	}
+	s.processed[eventID] = true
+	return nil
+}
+
+func TestIdempotency(t *testing.T) {
+	tests := []struct {
+		name      string
+		payloads  []string
+		wantCalls int
+	}{
+		{"evento novo", []string{`{"event_id":"e1"}`}, 1},
+		{"evento repetido é ignorado", []string{`{"event_id":"e1"}`, `{"event_id":"e1"}`}, 1},
+		{"eventos distintos", []string{`{"event_id":"e1"}`, `{"event_id":"e2"}`}, 2},
+		{"sem event_id sempre processa", []string{`{"id":1}`, `{"id":1}`}, 2},
+		{"payload inválido sempre processa", []string{"não é json", "não é json"}, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			handler := func(ctx context.Context, message Message) error {
+				calls++
+				return nil
+			}
+			chained := Chain(handler, Idempotency(&fakeIdempotencyStore{processed: make(map[string]bool)}))
+
+			for _, payload := range tt.payloads {
+				if err := chained(context.Background(), testMessage(payload)); err != nil {
+					t.Fatalf("Idempotency: %v", err)
+				}
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("handler chamado %d vezes, esperado %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+// errorString retorna a mensagem do erro ou "" para nil
+func errorString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
@@ -0,0 +1,68 @@
+package kafka
+
+import (
+	"sync"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// topicPartition identifica uma partição de um tópico
+type topicPartition struct {
+	topic     string
+	partition int
+}
+
+// offsetTracker acompanha as mensagens em processamento de cada partição e indica até onde o
+// offset pode ser confirmado: só a sequência contínua de mensagens concluídas, na ordem em que
+// foram lidas. Assim um commit nunca passa por cima de uma mensagem ainda em processamento.
+type offsetTracker struct {
+	mu         sync.Mutex
+	partitions map[topicPartition]*partitionOffsets
+}
+
+// partitionOffsets mensagens lidas de uma partição, em ordem de leitura
+type partitionOffsets struct {
+	pending []kafka.Message // Lidas e ainda não confirmáveis, na ordem de leitura
+	done    map[int64]bool  // Offsets concluídos fora de ordem
+}
+
+// newOffsetTracker cria um novo rastreador de offsets
+func newOffsetTracker() *offsetTracker {
+	return &offsetTracker{partitions: make(map[topicPartition]*partitionOffsets)}
+}
+
+// Start registra uma mensagem lida que ainda será processada
+func (t *offsetTracker) Start(message kafka.Message) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	partition, ok := t.partitions[topicPartition{message.Topic, message.Partition}]
+	if !ok {
+		partition = &partitionOffsets{done: make(map[int64]bool)}
+		t.partitions[topicPartition{message.Topic, message.Partition}] = partition
+	}
+	partition.pending = append(partition.pending, message)
+}
+
+// Done marca a mensagem como concluída e retorna a última mensagem da sequência contínua de
+// concluídas, que pode ser confirmada. Retorna false se o offset confirmável não avançou.
+func (t *offsetTracker) Done(message kafka.Message) (kafka.Message, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	partition, ok := t.partitions[topicPartition{message.Topic, message.Partition}]
+	if !ok {
+		return kafka.Message{}, false
+	}
+	partition.done[message.Offset] = true
+
+	var committable kafka.Message
+	advanced := false
+	for len(partition.pending) > 0 && partition.done[partition.pending[0].Offset] {
+		committable = partition.pending[0]
+		delete(partition.done, committable.Offset)
+		partition.pending = partition.pending[1:]
+		advanced = true
+	}
+	return committable, advanced
+}
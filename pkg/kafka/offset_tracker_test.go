@@ -0,0 +1,96 @@
+package kafka
+
+import (
+	"testing"
+
+	"github.com/segmentio/kafka-go"
+)
+
+func TestOffsetTrackerCommitsContiguousOffsets(t *testing.T) {
+	// step conclui a mensagem (partição, offset) e informa o offset confirmável esperado (-1 = nenhum)
+	type step struct {
+		partition  int
+		offset     int64
+		wantCommit int64
+	}
+
+	tests := []struct {
+		name    string
+		started map[int][]int64 // Offsets lidos por partição, na ordem de leitura
+		steps   []step
+	}{
+		{
+			name:    "em ordem",
+			started: map[int][]int64{0: {10, 11, 12}},
+			steps:   []step{{0, 10, 10}, {0, 11, 11}, {0, 12, 12}},
+		},
+		{
+			name:    "fora de ordem aguarda a lacuna",
+			started: map[int][]int64{0: {10, 11, 12}},
+			steps:   []step{{0, 12, -1}, {0, 11, -1}, {0, 10, 12}},
+		},
+		{
+			name:    "lacuna no meio",
+			started: map[int][]int64{0: {10, 11, 12, 13}},
+			steps:   []step{{0, 10, 10}, {0, 12, -1}, {0, 13, -1}, {0, 11, 13}},
+		},
+		{
+			name:    "partições independentes",
+			started: map[int][]int64{0: {10, 11}, 1: {20, 21}},
+			steps:   []step{{1, 21, -1}, {0, 10, 10}, {1, 20, 21}, {0, 11, 11}},
+		},
+		{
+			name:    "offsets não consecutivos (compactação)",
+			started: map[int][]int64{0: {10, 15, 30}},
+			steps:   []step{{0, 15, -1}, {0, 10, 15}, {0, 30, 30}},
+		},
+		{
+			name:    "partição desconhecida",
+			started: map[int][]int64{0: {10}},
+			steps:   []step{{1, 10, -1}, {0, 10, 10}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracker := newOffsetTracker()
+			for partition, offsets := range tt.started {
+				for _, offset := range offsets {
+					tracker.Start(kafka.Message{Topic: "order.created", Partition: partition, Offset: offset})
+				}
+			}
+
+			for _, s := range tt.steps {
+				committable, ok := tracker.Done(kafka.Message{Topic: "order.created", Partition: s.partition, Offset: s.offset})
+				switch {
+				case s.wantCommit < 0 && ok:
+					t.Errorf("Done(%d:%d) confirmou %d, esperado nenhum", s.partition, s.offset, committable.Offset)
+				case s.wantCommit >= 0 && !ok:
+					t.Errorf("Done(%d:%d) não confirmou, esperado %d", s.partition, s.offset, s.wantCommit)
+				case ok && (committable.Offset != s.wantCommit || committable.Partition != s.partition):
+					t.Errorf("Done(%d:%d) confirmou %d:%d, esperado %d:%d",
+						s.partition, s.offset, committable.Partition, committable.Offset, s.partition, s.wantCommit)
+				}
+			}
+		})
+	}
+}
+
+func TestOffsetTrackerSeparatesTopics(t *testing.T) {
+	// Com o Router, a mesma partição de tópicos distintos é rastreada separadamente
+	tracker := newOffsetTracker()
+	tracker.Start(kafka.Message{Topic: "order.created", Partition: 0, Offset: 10})
+	tracker.Start(kafka.Message{Topic: "order.paid", Partition: 0, Offset: 5})
+
+	committable, ok := tracker.Done(kafka.Message{Topic: "order.paid", Partition: 0, Offset: 5})
+	if !ok || committable.Topic != "order.paid" || committable.Offset != 5 {
+		t.Fatalf("Done(order.paid 0:5) = %s %d:%d, %v", committable.Topic, committable.Partition, committable.Offset, ok)
+	}
+	if _, ok := tracker.Done(kafka.Message{Topic: "order.paid", Partition: 0, Offset: 10}); ok {
+		t.Error("offset de order.created confirmado em order.paid")
+	}
+	committable, ok = tracker.Done(kafka.Message{Topic: "order.created", Partition: 0, Offset: 10})
+	if !ok || committable.Topic != "order.created" || committable.Offset != 10 {
+		t.Errorf("Done(order.created 0:10) = %s %d:%d, %v", committable.Topic, committable.Partition, committable.Offset, ok)
+	}
+}
@@ -3,53 +3,63 @@ package kafka
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"sort"
 	"time"
 
 	"github.com/rs/zerolog/log"
 	"github.com/segmentio/kafka-go"
 )
 
+// Headers padrão adicionados pelo producer a toda mensagem
+const (
+	HeaderContentType = "Content-Type"
+	HeaderTimestamp   = "Timestamp"
+)
+
 // Producer wrapper para o produtor Kafka
 type Producer struct {
-	writer *kafka.Writer
+	writer Writer
 }
 
 // NewProducer cria um novo produtor Kafka
 func NewProducer(brokers []string) *Producer {
-	writer := &kafka.Writer{
-		Addr:         kafka.TCP(brokers...),
-		Balancer:     &kafka.LeastBytes{},
-		RequiredAcks: kafka.RequireOne,
-		Async:        false, // Síncrono para garantir entrega
-		Logger:       kafka.LoggerFunc(log.Printf),
-	}
-	
-	return &Producer{writer: writer}
+	return NewProducerWithBroker(NewKafkaBroker(brokers))
 }
 
-// PublishEvent publica um evento no tópico especificado
+// NewProducerWithBroker cria um produtor sobre o transporte informado (ex: broker em memória)
+func NewProducerWithBroker(broker Broker) *Producer {
+	return &Producer{writer: broker.NewWriter()}
+}
+
+// PublishEvent publica um evento no tópico especificado, sem chave (o producer usa o timestamp
+// atual). Eventos de uma entidade devem usar PublishEventWithKey.
 func (p *Producer) PublishEvent(ctx context.Context, topic string, event interface{}) error {
+	return p.PublishEventWithKey(ctx, topic, nil, event)
+}
+
+// PublishEventWithKey serializa e publica um evento com a chave informada (ex: "order:42"), para
+// que eventos da mesma entidade caiam na mesma partição e sejam consumidos em ordem
+func (p *Producer) PublishEventWithKey(ctx context.Context, topic string, key []byte, event interface{}) error {
 	payload, err := json.Marshal(event)
 	if err != nil {
 		return fmt.Errorf("erro ao serializar evento: %w", err)
 	}
 	
-	message := kafka.Message{
-		Topic: topic,
-		Key:   []byte(fmt.Sprintf("%d", time.Now().UnixNano())),
-		Value: payload,
-		Headers: []kafka.Header{
-			{Key: "Content-Type", Value: []byte("application/json")},
-			{Key: "Timestamp", Value: []byte(time.Now().Format(time.RFC3339))},
-		},
-	}
-	
 	log.Info().
 		Str("topic", topic).
 		Str("event_type", fmt.Sprintf("%T", event)).
 		Msg("publicando evento no Kafka")
 	
+	return p.Publish(ctx, topic, key, payload, nil)
+}
+
+// Publish publica um payload já serializado, repassando os headers informados como
+// headers Kafka. Sem chave, usa o timestamp atual como chave da mensagem.
+func (p *Producer) Publish(ctx context.Context, topic string, key, value []byte, headers map[string]string) error {
+	message := toKafkaMessage(Message{Topic: topic, Key: key, Value: value, Headers: headers})
+	
 	if err := p.writer.WriteMessages(ctx, message); err != nil {
 		return fmt.Errorf("erro ao publicar evento no tópico %s: %w", topic, err)
 	}
@@ -61,18 +71,107 @@ func (p *Producer) PublishEvent(ctx context.Context, topic string, event interfa
 	return nil
 }
 
-// PublishToDLQ publica uma mensagem na DLQ
-func (p *Producer) PublishToDLQ(ctx context.Context, originalTopic string, event interface{}, errorMsg string) error {
-	dlqTopic := originalTopic + ".dlq"
+// PublishBatch publica um lote de mensagens em uma única chamada ao Kafka.
+// Em falha parcial retorna BatchError indicando quais mensagens não foram publicadas;
+// qualquer outro erro indica que nenhuma mensagem do lote foi confirmada.
+func (p *Producer) PublishBatch(ctx context.Context, messages []Message) error {
+	if len(messages) == 0 {
+		return nil
+	}
+	
+	kafkaMessages := make([]kafka.Message, len(messages))
+	for i, message := range messages {
+		kafkaMessages[i] = toKafkaMessage(message)
+	}
+	
+	if err := p.writer.WriteMessages(ctx, kafkaMessages...); err != nil {
+		var writeErrors kafka.WriteErrors
+		if errors.As(err, &writeErrors) && len(writeErrors) == len(messages) {
+			return BatchError(writeErrors)
+		}
+		return fmt.Errorf("erro ao publicar lote de %d mensagens: %w", len(messages), err)
+	}
 	
-	dlqEvent := map[string]interface{}{
-		"original_topic": originalTopic,
-		"error_message":  errorMsg,
-		"timestamp":      time.Now().Format(time.RFC3339),
-		"original_event": event,
+	log.Info().
+		Int("count", len(messages)).
+		Msg("lote publicado com sucesso")
+	
+	return nil
+}
+
+// toKafkaMessage converte a mensagem; sem chave, usa o timestamp atual como chave
+func toKafkaMessage(message Message) kafka.Message {
+	key := message.Key
+	if key == nil {
+		key = []byte(fmt.Sprintf("%d", time.Now().UnixNano()))
+	}
+	
+	return kafka.Message{
+		Topic:   message.Topic,
+		Key:     key,
+		Value:   message.Value,
+		Headers: buildHeaders(message.Headers),
+	}
+}
+
+// buildHeaders monta os headers Kafka padrão seguidos dos headers informados, em ordem estável
+func buildHeaders(headers map[string]string) []kafka.Header {
+	kafkaHeaders := []kafka.Header{
+		{Key: HeaderContentType, Value: []byte("application/json")},
+		{Key: HeaderTimestamp, Value: []byte(time.Now().Format(time.RFC3339))},
+	}
+	
+	keys := make([]string, 0, len(headers))
+	for key := range headers {
+		// Headers padrão recebidos de outra mensagem (ex: reencaminhamento) não são duplicados
+		if key == HeaderContentType || key == HeaderTimestamp {
+			continue
+		}
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	
+	for _, key := range keys {
+		kafkaHeaders = append(kafkaHeaders, kafka.Header{Key: key, Value: []byte(headers[key])})
+	}
+	
+	return kafkaHeaders
+}
+
+// HeadersToMap converte headers Kafka em um mapa (o último valor prevalece em chaves repetidas)
+func HeadersToMap(headers []kafka.Header) map[string]string {
+	result := make(map[string]string, len(headers))
+	for _, header := range headers {
+		result[header.Key] = string(header.Value)
+	}
+	return result
+}
+
+// PublishWithHeaders publica a mensagem com exatamente os headers informados, sem adicionar os
+// headers padrão. Usado para reencaminhar e republicar mensagens sem alterá-las.
+func (p *Producer) PublishWithHeaders(ctx context.Context, topic string, key, value []byte, headers []kafka.Header) error {
+	message := kafka.Message{
+		Topic:   topic,
+		Key:     key,
+		Value:   value,
+		Headers: headers,
+	}
+	
+	if err := p.writer.WriteMessages(ctx, message); err != nil {
+		return fmt.Errorf("erro ao publicar mensagem no tópico %s: %w", topic, err)
+	}
+	return nil
+}
+
+// PublishToDLQ publica o envelope na DLQ do tópico original e do consumer group que falhou, com a
+// chave original para manter a mensagem na mesma partição relativa
+func (p *Producer) PublishToDLQ(ctx context.Context, envelope DLQEnvelope) error {
+	payload, err := json.Marshal(envelope)
+	if err != nil {
+		return fmt.Errorf("erro ao serializar envelope da DLQ: %w", err)
 	}
 	
-	return p.PublishEvent(ctx, dlqTopic, dlqEvent)
+	return p.Publish(ctx, DLQTopic(envelope.OriginalTopic, envelope.ConsumerGroup), envelope.Key, payload, nil)
 }
 
 // Close fecha o produtor
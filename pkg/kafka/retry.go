@@ -0,0 +1,175 @@
+package kafka
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// Headers de controle adicionados quando uma mensagem é encaminhada para um tópico de retry.
+// Os dados da mensagem original são gravados no primeiro encaminhamento e mantidos nos seguintes.
+const (
+	HeaderRetryAttempt      = "retry_attempt"      // Quantas vezes o handler já falhou para esta mensagem
+	HeaderLastError         = "last_error"         // Erro da última tentativa
+	HeaderFirstFailureAt    = "first_failure_at"   // Instante da primeira falha
+	HeaderOriginalTopic     = "original_topic"     // Tópico em que a mensagem foi publicada originalmente
+	HeaderOriginalPartition = "original_partition" // Partição no tópico original
+	HeaderOriginalOffset    = "original_offset"    // Offset no tópico original
+	HeaderOriginalTimestamp = "original_timestamp" // Timestamp da mensagem no tópico original
+	HeaderRetryNotBefore    = "retry_not_before"   // Instante mínimo da próxima tentativa (erro Retryable)
+)
+
+// retryControlHeaders headers de controle, que não fazem parte da mensagem original
+var retryControlHeaders = map[string]bool{
+	HeaderRetryAttempt:      true,
+	HeaderLastError:         true,
+	HeaderFirstFailureAt:    true,
+	HeaderOriginalTopic:     true,
+	HeaderOriginalPartition: true,
+	HeaderOriginalOffset:    true,
+	HeaderOriginalTimestamp: true,
+	HeaderRetryNotBefore:    true,
+}
+
+// DefaultRetryDelays atrasos padrão dos tópicos de retry (<tópico>.<grupo>.retry.5s e .retry.1m)
+var DefaultRetryDelays = []time.Duration{5 * time.Second, time.Minute}
+
+// RetryTier nível de retry: mensagens publicadas em Topic só são reprocessadas após Delay
+type RetryTier struct {
+	Topic string
+	Delay time.Duration
+}
+
+// RetryTopic retorna o nome do tópico de retry do consumer group com o atraso informado (ex:
+// order.created.product-consumer.retry.5s). Cada grupo tem os seus: uma falha em um grupo não é
+// reprocessada pelos demais, que já consumiram a mensagem com sucesso.
+func RetryTopic(topic, groupID string, delay time.Duration) string {
+	return fmt.Sprintf("%s.%s.retry.%s", topic, groupID, formatDelay(delay))
+}
+
+// retryTiers monta os níveis de retry de um tópico para o consumer group a partir dos atrasos
+func retryTiers(topic, groupID string, delays []time.Duration) []RetryTier {
+	tiers := make([]RetryTier, len(delays))
+	for i, delay := range delays {
+		tiers[i] = RetryTier{Topic: RetryTopic(topic, groupID, delay), Delay: delay}
+	}
+	return tiers
+}
+
+// formatDelay formata o atraso na maior unidade exata (5s, 1m, 2h), evitando nomes como "1m0s"
+func formatDelay(delay time.Duration) string {
+	switch {
+	case delay%time.Hour == 0:
+		return fmt.Sprintf("%dh", delay/time.Hour)
+	case delay%time.Minute == 0:
+		return fmt.Sprintf("%dm", delay/time.Minute)
+	case delay%time.Second == 0:
+		return fmt.Sprintf("%ds", delay/time.Second)
+	default:
+		return fmt.Sprintf("%dms", delay/time.Millisecond)
+	}
+}
+
+// ParseRetryDelays interpreta uma lista de atrasos no formato "5s,1m" (vazio = sem retry)
+func ParseRetryDelays(spec string) ([]time.Duration, error) {
+	var delays []time.Duration
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		delay, err := time.ParseDuration(entry)
+		if err != nil || delay <= 0 {
+			return nil, fmt.Errorf("atraso de retry inválido: %q", entry)
+		}
+		delays = append(delays, delay)
+	}
+	return delays, nil
+}
+
+// retryStageFor escolhe, a partir do estágio atual, o primeiro nível de retry cujo atraso cobre o
+// atraso sugerido pelo handler e retorna também quanto aguardar nele. Se nenhum nível cobrir, usa o
+// último e limita a espera ao atraso dele: esperar mais bloquearia a leitura do nível, que é
+// compartilhada com as demais mensagens.
+func retryStageFor(tiers []RetryTier, stage int, after time.Duration) (int, time.Duration) {
+	for i := stage; i < len(tiers); i++ {
+		if tiers[i].Delay >= after {
+			return i, after
+		}
+	}
+	last := len(tiers) - 1
+	return last, tiers[last].Delay
+}
+
+// retryNotBefore lê o instante mínimo da próxima tentativa (zero se não houver)
+func retryNotBefore(headers []kafka.Header) time.Time {
+	for _, header := range headers {
+		if header.Key == HeaderRetryNotBefore {
+			notBefore, _ := time.Parse(time.RFC3339Nano, string(header.Value))
+			return notBefore
+		}
+	}
+	return time.Time{}
+}
+
+// retryAttempt lê o número de tentativas já realizadas a partir dos headers
+func retryAttempt(headers map[string]string) int {
+	attempt, err := strconv.Atoi(headers[HeaderRetryAttempt])
+	if err != nil {
+		return 0
+	}
+	return attempt
+}
+
+// originalHeaders retorna os headers da mensagem sem os headers de controle de retry
+func originalHeaders(headers []kafka.Header) []kafka.Header {
+	result := make([]kafka.Header, 0, len(headers))
+	for _, header := range headers {
+		if !retryControlHeaders[header.Key] {
+			result = append(result, header)
+		}
+	}
+	return result
+}
+
+// retryHeaders monta os headers da mensagem encaminhada ao tópico de retry: os headers originais
+// inalterados seguidos dos headers de controle atualizados. notBefore (se não for zero) registra o
+// instante mínimo da próxima tentativa sugerido por um erro Retryable.
+func retryHeaders(message kafka.Message, attempt int, cause error, now, notBefore time.Time) []kafka.Header {
+	current := HeadersToMap(message.Headers)
+
+	control := map[string]string{
+		HeaderRetryAttempt:      strconv.Itoa(attempt),
+		HeaderLastError:         cause.Error(),
+		HeaderFirstFailureAt:    now.Format(time.RFC3339Nano),
+		HeaderOriginalTopic:     message.Topic,
+		HeaderOriginalPartition: strconv.Itoa(message.Partition),
+		HeaderOriginalOffset:    strconv.FormatInt(message.Offset, 10),
+		HeaderOriginalTimestamp: message.Time.Format(time.RFC3339Nano),
+	}
+	if !notBefore.IsZero() {
+		control[HeaderRetryNotBefore] = notBefore.Format(time.RFC3339Nano)
+	}
+	// Nos níveis seguintes, os dados da mensagem original e da primeira falha são mantidos
+	for _, key := range []string{HeaderFirstFailureAt, HeaderOriginalTopic, HeaderOriginalPartition, HeaderOriginalOffset, HeaderOriginalTimestamp} {
+		if value, ok := current[key]; ok {
+			control[key] = value
+		}
+	}
+
+	headers := originalHeaders(message.Headers)
+	keys := make([]string, 0, len(control))
+	for key := range control {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		headers = append(headers, kafka.Header{Key: key, Value: []byte(control[key])})
+	}
+	return headers
+}
@@ -0,0 +1,54 @@
+package kafka
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+func TestRetryStageFor(t *testing.T) {
+	tiers := retryTiers("order.created", "query-consumer", []time.Duration{5 * time.Second, time.Minute, 10 * time.Minute})
+
+	tests := []struct {
+		name      string
+		stage     int
+		after     time.Duration
+		wantStage int
+		wantWait  time.Duration
+	}{
+		{"coberto pelo nível atual", 0, 3 * time.Second, 0, 3 * time.Second},
+		{"igual ao atraso do nível", 0, 5 * time.Second, 0, 5 * time.Second},
+		{"avança até o nível que cobre", 0, 30 * time.Second, 1, 30 * time.Second},
+		{"não volta a níveis anteriores", 2, time.Second, 2, time.Second},
+		{"maior que todos os níveis", 0, time.Hour, 2, 10 * time.Minute},
+		{"maior que o último a partir dele", 2, 20 * time.Minute, 2, 10 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stage, wait := retryStageFor(tiers, tt.stage, tt.after)
+			if stage != tt.wantStage || wait != tt.wantWait {
+				t.Errorf("retryStageFor(%d, %s) = (%d, %s), esperado (%d, %s)",
+					tt.stage, tt.after, stage, wait, tt.wantStage, tt.wantWait)
+			}
+		})
+	}
+}
+
+func TestRetryHeadersNotBefore(t *testing.T) {
+	message := kafka.Message{Topic: "order.created", Key: []byte("order:42"), Time: time.Now()}
+	cause := errors.New("limite de taxa")
+	now := time.Now()
+
+	headers := retryHeaders(message, 1, cause, now, now.Add(time.Minute))
+	if got := retryNotBefore(headers); !got.Equal(now.Add(time.Minute)) {
+		t.Errorf("retry_not_before = %s, esperado %s", got, now.Add(time.Minute))
+	}
+
+	headers = retryHeaders(message, 1, cause, now, time.Time{})
+	if got := retryNotBefore(headers); !got.IsZero() {
+		t.Errorf("retry_not_before = %s, esperado vazio", got)
+	}
+}
@@ -0,0 +1,552 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+	pkgevents "pkg/events"
+
+	"github.com/rs/zerolog/log"
+	"github.com/segmentio/kafka-go"
+)
+
+// RouteOptions opções de uma rota do Router
+type RouteOptions struct {
+	RetryDelays []time.Duration // Atrasos dos tópicos de retry; nil usa o padrão do Router, vazio desativa
+	DisableDLQ  bool            // Descarta (com log) em vez de enviar à DLQ após o último nível
+	Middlewares []Middleware    // Aplicados depois (por dentro) dos middlewares do Router
+}
+
+// RouterHooks ganchos do ciclo de vida do Router (campos nil são ignorados)
+type RouterHooks struct {
+	OnStart   func(ctx context.Context, topics []string) error // Antes da leitura; erro interrompe Run
+	OnStop    func()                                           // Depois que todos os readers foram fechados
+	OnFailure func(topic string, err error)                    // Handler falhou, antes do encaminhamento
+	OnDLQ     func(envelope DLQEnvelope)                       // Mensagem enviada para a DLQ
+}
+
+// Router consome vários tópicos com um único consumer group e despacha cada mensagem para o
+// handler registrado para o tópico ou, se houver, para o event_type da mensagem.
+type Router struct {
+	broker      Broker
+	groupID     string
+	producer    *Producer
+	retryDelays []time.Duration
+	workers     int
+	hooks       RouterHooks
+	middlewares []Middleware
+	breaker     *CircuitBreaker
+	routes      map[string]*route
+}
+
+// route handlers e opções de um tópico
+type route struct {
+	topic    string
+	handler  *registeredHandler            // Handler do tópico (fallback quando não há handler do evento)
+	events   map[string]*registeredHandler // Handlers por event_type
+	options  RouteOptions
+	tiers    []RetryTier
+	hasRetry bool // options.RetryDelays foi informado
+}
+
+// registeredHandler handler registrado e o nome da função original, gravado no envelope da DLQ
+type registeredHandler struct {
+	handler Handler
+	name    string
+}
+
+// binding associa um tópico lido à rota e ao estágio (0 = tópico principal, N = N-ésimo retry)
+type binding struct {
+	route *route
+	stage int
+}
+
+// NewRouter cria um novo router para o consumer group informado
+func NewRouter(brokers []string, groupID string, producer *Producer) *Router {
+	return NewRouterWithBroker(NewKafkaBroker(brokers), groupID, producer)
+}
+
+// NewRouterWithBroker cria um router que lê do transporte informado (ex: broker em memória)
+func NewRouterWithBroker(broker Broker, groupID string, producer *Producer) *Router {
+	return &Router{
+		broker:      broker,
+		groupID:     groupID,
+		producer:    producer,
+		retryDelays: DefaultRetryDelays,
+		workers:     1,
+		routes:      make(map[string]*route),
+	}
+}
+
+// SetRetryDelays define os atrasos padrão dos tópicos de retry das rotas
+func (r *Router) SetRetryDelays(delays []time.Duration) {
+	r.retryDelays = delays
+}
+
+// SetWorkers define quantas mensagens são processadas em paralelo por reader. A ordem é mantida
+// por chave: mensagens com a mesma chave são processadas pelo mesmo worker, na ordem de leitura.
+func (r *Router) SetWorkers(workers int) {
+	if workers < 1 {
+		workers = 1
+	}
+	r.workers = workers
+}
+
+// SetHooks define os ganchos do ciclo de vida
+func (r *Router) SetHooks(hooks RouterHooks) {
+	r.hooks = hooks
+}
+
+// SetCircuitBreaker define o circuit breaker compartilhado por todos os readers do Router (nil
+// desativa). Com o circuito aberto ou o consumo pausado, nenhuma mensagem é lida nem confirmada.
+func (r *Router) SetCircuitBreaker(breaker *CircuitBreaker) {
+	r.breaker = breaker
+}
+
+// Use adiciona middlewares aplicados a todos os handlers, na ordem informada (o primeiro é o
+// mais externo)
+func (r *Router) Use(middlewares ...Middleware) {
+	r.middlewares = append(r.middlewares, middlewares...)
+}
+
+// Handle registra o handler de um tópico com as opções padrão
+func (r *Router) Handle(topic string, handler MessageHandler) {
+	r.routeFor(topic).handler = &registeredHandler{handler: AdaptMessageHandler(handler), name: handlerName(handler)}
+}
+
+// HandleMessage igual a Handle, com um handler que recebe a mensagem completa
+func (r *Router) HandleMessage(topic string, handler Handler) {
+	r.routeFor(topic).handler = &registeredHandler{handler: handler, name: handlerName(handler)}
+}
+
+// HandleWithOptions registra o handler de um tópico com opções próprias de retry e DLQ
+func (r *Router) HandleWithOptions(topic string, handler MessageHandler, options RouteOptions) {
+	r.Handle(topic, handler)
+	r.setOptions(topic, options)
+}
+
+// HandleMessageWithOptions igual a HandleWithOptions, com um handler que recebe a mensagem completa
+func (r *Router) HandleMessageWithOptions(topic string, handler Handler, options RouteOptions) {
+	r.HandleMessage(topic, handler)
+	r.setOptions(topic, options)
+}
+
+// HandleEvent registra o handler de um event_type (header event_type) publicado no tópico,
+// para tópicos que agrupam vários eventos (ex: "orders" com order.created e order.paid)
+func (r *Router) HandleEvent(topic, eventType string, handler MessageHandler) {
+	r.routeFor(topic).events[eventType] = &registeredHandler{handler: AdaptMessageHandler(handler), name: handlerName(handler)}
+}
+
+// HandleEventMessage igual a HandleEvent, com um handler que recebe a mensagem completa
+func (r *Router) HandleEventMessage(topic, eventType string, handler Handler) {
+	r.routeFor(topic).events[eventType] = &registeredHandler{handler: handler, name: handlerName(handler)}
+}
+
+// setOptions define as opções de retry e DLQ da rota
+func (r *Router) setOptions(topic string, options RouteOptions) {
+	rt := r.routeFor(topic)
+	rt.options = options
+	rt.hasRetry = options.RetryDelays != nil
+}
+
+// routeFor retorna a rota do tópico, criando-a se necessário
+func (r *Router) routeFor(topic string) *route {
+	rt, ok := r.routes[topic]
+	if !ok {
+		rt = &route{topic: topic, events: make(map[string]*registeredHandler)}
+		r.routes[topic] = rt
+	}
+	return rt
+}
+
+// RegisterTopics registra no registry os tópicos lidos pelo Router: cada tópico principal, os
+// tópicos de retry do grupo com os atrasos da rota (RouteOptions.RetryDelays ou o padrão do Router)
+// e a DLQ do grupo, exceto nas rotas com DisableDLQ
+func (r *Router) RegisterTopics(registry *TopicRegistry, defaults TopicDefaults) {
+	for _, topic := range r.Topics() {
+		rt := r.routes[topic]
+		registry.Register(eventTopicSpec(topic, defaults))
+		registry.registerGroupTopics(topic, r.groupID, rt.retryDelays(r.retryDelays), !rt.options.DisableDLQ, defaults)
+	}
+}
+
+// retryDelays retorna os atrasos de retry da rota ou, se não informados, os padrão do Router
+func (rt *route) retryDelays(defaults []time.Duration) []time.Duration {
+	if rt.hasRetry {
+		return rt.options.RetryDelays
+	}
+	return defaults
+}
+
+// Topics retorna os tópicos principais registrados, em ordem alfabética
+func (r *Router) Topics() []string {
+	topics := make([]string, 0, len(r.routes))
+	for topic := range r.routes {
+		topics = append(topics, topic)
+	}
+	sort.Strings(topics)
+	return topics
+}
+
+// Run consome os tópicos registrados até o contexto ser cancelado. Um único group reader lê
+// todos os tópicos principais e um group reader por atraso lê os tópicos de retry do grupo. O offset só
+// é confirmado depois que o handler processa a mensagem com sucesso ou que ela é encaminhada
+// para o próximo nível de retry ou para a DLQ (entrega at-least-once).
+func (r *Router) Run(ctx context.Context) error {
+	if len(r.routes) == 0 {
+		return fmt.Errorf("nenhuma rota registrada no router")
+	}
+
+	// Agrupa os tópicos lidos: principais em um reader, retry em um reader por atraso
+	bindings := make(map[string]binding)
+	readerTopics := map[time.Duration][]string{}
+	for _, topic := range r.Topics() {
+		rt := r.routes[topic]
+		rt.tiers = retryTiers(topic, r.groupID, rt.retryDelays(r.retryDelays))
+
+		bindings[topic] = binding{route: rt, stage: 0}
+		readerTopics[0] = append(readerTopics[0], topic)
+		for i, tier := range rt.tiers {
+			bindings[tier.Topic] = binding{route: rt, stage: i + 1}
+			readerTopics[tier.Delay] = append(readerTopics[tier.Delay], tier.Topic)
+		}
+	}
+
+	if r.hooks.OnStart != nil {
+		if err := r.hooks.OnStart(ctx, r.Topics()); err != nil {
+			return err
+		}
+	}
+
+	log.Info().
+		Str("group_id", r.groupID).
+		Strs("topics", r.Topics()).
+		Int("workers", r.workers).
+		Msg("iniciando consumo de mensagens")
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, len(readerTopics))
+	for delay, topics := range readerTopics {
+		reader := r.broker.NewGroupReader(topics, r.groupID)
+		wg.Add(1)
+		go func(delay time.Duration) {
+			defer wg.Done()
+			// Fechar o reader envia os offsets confirmados ainda pendentes
+			defer reader.Close()
+			errs <- r.consumeLoop(ctx, reader, delay, bindings)
+			// Se um reader parar, os demais também param
+			cancel()
+		}(delay)
+	}
+	wg.Wait()
+	close(errs)
+
+	if r.hooks.OnStop != nil {
+		r.hooks.OnStop()
+	}
+	log.Info().Str("group_id", r.groupID).Msg("consumo interrompido")
+
+	// Retorna o primeiro erro diferente do cancelamento, se houver
+	var result error
+	for err := range errs {
+		if result == nil || result == context.Canceled {
+			result = err
+		}
+	}
+	return result
+}
+
+// consumeLoop lê as mensagens de um reader e as distribui entre os workers pela chave. Nos
+// readers de retry (delay > 0), cada mensagem aguarda o atraso contado a partir da publicação.
+func (r *Router) consumeLoop(ctx context.Context, reader Reader, delay time.Duration, bindings map[string]binding) error {
+	tracker := newOffsetTracker()
+
+	queues := make([]chan kafka.Message, r.workers)
+	var wg sync.WaitGroup
+	for i := range queues {
+		queues[i] = make(chan kafka.Message, workerQueueSize)
+		wg.Add(1)
+		go func(queue <-chan kafka.Message) {
+			defer wg.Done()
+			r.work(ctx, reader, tracker, queue, bindings)
+		}(queues[i])
+	}
+	defer func() {
+		for _, queue := range queues {
+			close(queue)
+		}
+		wg.Wait()
+	}()
+
+	for {
+		// Com o circuito aberto ou o consumo pausado, a leitura aguarda
+		if err := r.breaker.waitFetch(ctx); err != nil {
+			return err
+		}
+
+		// FetchMessage não confirma o offset, ao contrário de ReadMessage
+		message, err := reader.FetchMessage(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			log.Error().Err(err).Str("group_id", r.groupID).Msg("erro ao ler mensagem")
+			continue
+		}
+
+		if delay > 0 {
+			// O atraso sugerido por um erro Retryable prevalece sobre o do nível, se for maior
+			until := message.Time.Add(delay)
+			if notBefore := retryNotBefore(message.Headers); notBefore.After(until) {
+				until = notBefore
+			}
+			if err := sleepUntil(ctx, until); err != nil {
+				return err
+			}
+		}
+
+		tracker.Start(message)
+		select {
+		case queues[workerFor(message.Key, len(queues))] <- message:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// work processa as mensagens de um worker em ordem e confirma o offset até onde todas as
+// mensagens anteriores da partição já foram concluídas
+func (r *Router) work(ctx context.Context, reader Reader, tracker *offsetTracker, queue <-chan kafka.Message, bindings map[string]binding) {
+	// A mensagem em processamento não é interrompida pelo desligamento: o handler termina e o
+	// offset é registrado antes de o reader ser fechado (o que envia os commits pendentes)
+	handleCtx := context.WithoutCancel(ctx)
+
+	for message := range queue {
+		if ctx.Err() != nil {
+			// Em desligamento: mensagens ainda não iniciadas não são confirmadas e serão relidas
+			continue
+		}
+
+		if !r.handle(ctx, handleCtx, message, bindings[message.Topic]) {
+			continue
+		}
+
+		committable, ok := tracker.Done(message)
+		if !ok {
+			continue
+		}
+		if err := reader.CommitMessages(handleCtx, committable); err != nil {
+			log.Error().
+				Err(err).
+				Str("topic", committable.Topic).
+				Int("partition", committable.Partition).
+				Int64("offset", committable.Offset).
+				Msg("erro ao confirmar offset")
+		}
+	}
+}
+
+// handle processa a mensagem com handleCtx e, em caso de falha, a encaminha. Retorna false se o
+// offset não pode ser confirmado (falha durante o desligamento).
+func (r *Router) handle(ctx, handleCtx context.Context, message kafka.Message, b binding) bool {
+	headers := HeadersToMap(message.Headers)
+
+	registered := b.route.handlerFor(headers[pkgevents.HeaderEventType])
+	if registered == nil {
+		log.Warn().
+			Str("topic", message.Topic).
+			Str("event_type", headers[pkgevents.HeaderEventType]).
+			Msg("nenhum handler registrado para a mensagem; ignorando")
+		return true
+	}
+
+	handler := Chain(registered.handler, b.route.middlewares(r.middlewares)...)
+
+	var err error
+	failedProbes := 0
+	for {
+		probe, waitErr := r.breaker.acquire(ctx)
+		if waitErr != nil {
+			// Desligamento com o circuito aberto: sem commit, a mensagem será relida
+			return false
+		}
+
+		err = process(handleCtx, fromKafkaMessage(message, headers), handler)
+		failed := err != nil && !IsPermanent(err)
+		if probe && failed {
+			failedProbes++
+		}
+		// Com a dependência indisponível, a mensagem aguarda o circuito fechar em vez de
+		// consumir os níveis de retry
+		if !r.breaker.release(probe, failed, err) || ctx.Err() != nil {
+			break
+		}
+		// Falhou em todas as sondagens: pode ser a mensagem, e não a dependência
+		if r.breaker.probesExhausted(failedProbes) {
+			log.Warn().
+				Err(err).
+				Str("topic", message.Topic).
+				Int64("offset", message.Offset).
+				Int("failed_probes", failedProbes).
+				Msg("mensagem falhou em todas as sondagens; seguindo para o retry")
+			break
+		}
+	}
+	if err == nil {
+		return true
+	}
+	if ctx.Err() != nil {
+		// Falhou durante o desligamento: sem commit, a mensagem será relida e encaminhada depois
+		return false
+	}
+
+	if r.hooks.OnFailure != nil {
+		r.hooks.OnFailure(message.Topic, err)
+	}
+
+	// Encaminha para o próximo nível; o offset só avança depois que o broker aceitar
+	return r.forward(ctx, message, headers, b, registered.name, err) == nil
+}
+
+// handlerFor retorna o handler do event_type ou, se não houver, o handler do tópico
+func (rt *route) handlerFor(eventType string) *registeredHandler {
+	if handler, ok := rt.events[eventType]; ok {
+		return handler
+	}
+	return rt.handler
+}
+
+// middlewares retorna os middlewares do Router seguidos dos middlewares da rota
+func (rt *route) middlewares(global []Middleware) []Middleware {
+	if len(rt.options.Middlewares) == 0 {
+		return global
+	}
+	return append(append([]Middleware{}, global...), rt.options.Middlewares...)
+}
+
+// process executa o handler uma única vez, com os metadados da mensagem no contexto. O log do
+// resultado fica a cargo do middleware Logging.
+func process(ctx context.Context, message Message, handler Handler) error {
+	// Disponibiliza os metadados (headers) da mensagem também para handlers que só recebem o valor
+	handlerCtx := pkgevents.ContextWithMetadata(ctx, message.Metadata())
+	return handler(handlerCtx, message)
+}
+
+// forward encaminha a mensagem com falha para o próximo nível de retry ou, após o último, para
+// a DLQ. Erros Permanent vão direto para a DLQ; erros Retryable com atraso sugerido avançam até o
+// nível que cobre o atraso. Tenta novamente com backoff até conseguir; só retorna erro se o
+// contexto for cancelado.
+func (r *Router) forward(ctx context.Context, message kafka.Message, headers map[string]string, b binding, handler string, cause error) error {
+	attempt := retryAttempt(headers) + 1
+	now := time.Now()
+	permanent := IsPermanent(cause)
+
+	stage := b.stage
+	var notBefore time.Time
+	if permanent {
+		stage = len(b.route.tiers)
+	} else if after, ok := RetryAfter(cause); ok && stage < len(b.route.tiers) {
+		var wait time.Duration
+		stage, wait = retryStageFor(b.route.tiers, stage, after)
+		if wait < after {
+			log.Warn().
+				Str("topic", message.Topic).
+				Dur("retry_after", after).
+				Dur("max_delay", wait).
+				Msg("atraso sugerido maior que o último nível de retry; limitado ao atraso do nível")
+		}
+		notBefore = now.Add(wait)
+	}
+
+	var target string
+	var publish func() error
+	switch {
+	case stage < len(b.route.tiers):
+		// Chave, valor e headers originais seguem inalterados; só os headers de controle mudam
+		target = b.route.tiers[stage].Topic
+		forwardHeaders := retryHeaders(message, attempt, cause, now, notBefore)
+		publish = func() error {
+			return r.producer.PublishWithHeaders(ctx, target, message.Key, message.Value, forwardHeaders)
+		}
+	case b.route.options.DisableDLQ:
+		log.Warn().
+			Err(cause).
+			Str("topic", message.Topic).
+			Int64("offset", message.Offset).
+			Int("attempt", attempt).
+			Bool("permanent", permanent).
+			Msg("mensagem descartada sem novas tentativas (DLQ desativada)")
+		return nil
+	default:
+		envelope := newDLQEnvelope(message, r.groupID, handler, cause, now)
+		target = DLQTopic(envelope.OriginalTopic, envelope.ConsumerGroup)
+		publish = func() error {
+			if err := r.producer.PublishToDLQ(ctx, envelope); err != nil {
+				return err
+			}
+			if r.hooks.OnDLQ != nil {
+				r.hooks.OnDLQ(envelope)
+			}
+			return nil
+		}
+	}
+
+	log.Warn().
+		Err(cause).
+		Str("topic", message.Topic).
+		Int("partition", message.Partition).
+		Int64("offset", message.Offset).
+		Int("attempt", attempt).
+		Bool("permanent", permanent).
+		Str("target", target).
+		Msg("encaminhando mensagem com falha")
+
+	backoff := publishRetryBaseBackoff
+	for {
+		err := publish()
+		if err == nil {
+			return nil
+		}
+
+		log.Error().
+			Err(err).
+			Str("target", target).
+			Int64("offset", message.Offset).
+			Dur("backoff", backoff).
+			Msg("erro ao encaminhar mensagem com falha")
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+
+		backoff *= 2
+		if backoff > publishRetryMaxBackoff {
+			backoff = publishRetryMaxBackoff
+		}
+	}
+}
+
+// sleepUntil aguarda até o instante informado ou até o contexto ser cancelado
+func sleepUntil(ctx context.Context, until time.Time) error {
+	wait := time.Until(until)
+	if wait <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,352 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/segmentio/kafka-go"
+)
+
+// Configurações de tópico verificadas pelo TopicAdmin
+const (
+	configRetention     = "retention.ms"
+	configCleanupPolicy = "cleanup.policy"
+)
+
+// Campos comparados entre a especificação e o cluster
+const (
+	FieldPartitions        = "partitions"
+	FieldReplicationFactor = "replication_factor"
+	FieldRetention         = configRetention
+	FieldCleanupPolicy     = configCleanupPolicy
+)
+
+// TopicMismatch divergência entre a especificação e o tópico existente no cluster
+type TopicMismatch struct {
+	Topic    string
+	Field    string
+	Expected string
+	Actual   string
+	Fixable  bool // Corrigível por Migrate (configuração ou aumento de partições)
+}
+
+func (m TopicMismatch) String() string {
+	return fmt.Sprintf("%s: %s esperado %s, encontrado %s", m.Topic, m.Field, m.Expected, m.Actual)
+}
+
+// TopicMismatchError tópicos existentes que divergem da especificação
+type TopicMismatchError struct {
+	Mismatches []TopicMismatch
+}
+
+func (e *TopicMismatchError) Error() string {
+	descriptions := make([]string, len(e.Mismatches))
+	for i, mismatch := range e.Mismatches {
+		descriptions[i] = mismatch.String()
+	}
+	return fmt.Sprintf("%d divergência(s) entre os tópicos e a especificação: %s", len(e.Mismatches), strings.Join(descriptions, "; "))
+}
+
+// TopicPlan resultado da comparação do registry com o cluster
+type TopicPlan struct {
+	Missing    []TopicSpec     // Tópicos a criar
+	Mismatches []TopicMismatch // Tópicos existentes com divergências
+	InSync     []string        // Tópicos existentes conforme a especificação
+}
+
+// TopicAdmin reconcilia o TopicRegistry com o cluster Kafka usando as APIs administrativas
+type TopicAdmin struct {
+	client *kafka.Client
+}
+
+// NewTopicAdmin cria o administrador de tópicos para os brokers informados
+func NewTopicAdmin(brokers []string) *TopicAdmin {
+	return &TopicAdmin{client: &kafka.Client{
+		Addr:    kafka.TCP(brokers...),
+		Timeout: 30 * time.Second,
+	}}
+}
+
+// Plan compara o registry com o cluster sem alterar nada
+func (a *TopicAdmin) Plan(ctx context.Context, registry *TopicRegistry) (*TopicPlan, error) {
+	specs := registry.Specs()
+	names := make([]string, len(specs))
+	for i, spec := range specs {
+		names[i] = spec.Name
+	}
+
+	metadata, err := a.client.Metadata(ctx, &kafka.MetadataRequest{Topics: names})
+	if err != nil {
+		return nil, fmt.Errorf("erro ao consultar os tópicos do cluster: %w", err)
+	}
+	topics := make(map[string]kafka.Topic, len(metadata.Topics))
+	for _, topic := range metadata.Topics {
+		topics[topic.Name] = topic
+	}
+
+	plan := &TopicPlan{}
+	var existing []string
+	for _, spec := range specs {
+		topic, ok := topics[spec.Name]
+		switch {
+		case !ok || errors.Is(topic.Error, kafka.UnknownTopicOrPartition):
+			plan.Missing = append(plan.Missing, spec)
+		case topic.Error != nil:
+			return nil, fmt.Errorf("erro ao consultar o tópico %s: %w", spec.Name, topic.Error)
+		default:
+			existing = append(existing, spec.Name)
+		}
+	}
+
+	configs, err := a.describeConfigs(ctx, existing)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, name := range existing {
+		spec, _ := registry.Lookup(name)
+		mismatches := compareTopic(spec, topics[name], configs[name])
+		if len(mismatches) == 0 {
+			plan.InSync = append(plan.InSync, name)
+			continue
+		}
+		plan.Mismatches = append(plan.Mismatches, mismatches...)
+	}
+	return plan, nil
+}
+
+// Reconcile cria os tópicos ausentes e falha se algum tópico existente divergir da especificação.
+// Usado na inicialização dos serviços: divergências são corrigidas com migrate-topics.
+func (a *TopicAdmin) Reconcile(ctx context.Context, registry *TopicRegistry) (*TopicPlan, error) {
+	plan, err := a.Plan(ctx, registry)
+	if err != nil {
+		return nil, err
+	}
+	if err := a.create(ctx, plan.Missing); err != nil {
+		return plan, err
+	}
+	if len(plan.Mismatches) > 0 {
+		return plan, &TopicMismatchError{Mismatches: plan.Mismatches}
+	}
+	return plan, nil
+}
+
+// Migrate cria os tópicos ausentes e corrige as divergências possíveis: retenção e cleanup.policy
+// são alterados e partições são acrescentadas (o que muda a partição de chaves existentes).
+// Divergências sem correção automática (menos partições ou outro fator de replicação) são
+// retornadas em TopicMismatchError.
+func (a *TopicAdmin) Migrate(ctx context.Context, registry *TopicRegistry) (*TopicPlan, error) {
+	plan, err := a.Plan(ctx, registry)
+	if err != nil {
+		return nil, err
+	}
+	if err := a.create(ctx, plan.Missing); err != nil {
+		return plan, err
+	}
+
+	var unfixable []TopicMismatch
+	for _, mismatch := range plan.Mismatches {
+		if !mismatch.Fixable {
+			unfixable = append(unfixable, mismatch)
+			continue
+		}
+		spec, _ := registry.Lookup(mismatch.Topic)
+		if err := a.fix(ctx, spec, mismatch); err != nil {
+			return plan, err
+		}
+		log.Info().Str("topic", mismatch.Topic).Str("field", mismatch.Field).Str("value", mismatch.Expected).Msg("tópico ajustado")
+	}
+
+	if len(unfixable) > 0 {
+		return plan, &TopicMismatchError{Mismatches: unfixable}
+	}
+	return plan, nil
+}
+
+// create cria os tópicos informados; tópicos criados ao mesmo tempo por outro serviço são aceitos
+func (a *TopicAdmin) create(ctx context.Context, specs []TopicSpec) error {
+	if len(specs) == 0 {
+		return nil
+	}
+
+	topics := make([]kafka.TopicConfig, len(specs))
+	for i, spec := range specs {
+		topics[i] = kafka.TopicConfig{
+			Topic:             spec.Name,
+			NumPartitions:     spec.Partitions,
+			ReplicationFactor: spec.ReplicationFactor,
+			ConfigEntries:     topicConfigEntries(spec),
+		}
+	}
+
+	response, err := a.client.CreateTopics(ctx, &kafka.CreateTopicsRequest{Topics: topics})
+	if err != nil {
+		return fmt.Errorf("erro ao criar tópicos: %w", err)
+	}
+	for _, spec := range specs {
+		if err := response.Errors[spec.Name]; err != nil {
+			if errors.Is(err, kafka.TopicAlreadyExists) {
+				continue
+			}
+			return fmt.Errorf("erro ao criar o tópico %s: %w", spec.Name, err)
+		}
+		log.Info().
+			Str("topic", spec.Name).
+			Int("partitions", spec.Partitions).
+			Int("replication_factor", spec.ReplicationFactor).
+			Msg("tópico criado")
+	}
+	return nil
+}
+
+// fix corrige uma divergência corrigível do tópico
+func (a *TopicAdmin) fix(ctx context.Context, spec TopicSpec, mismatch TopicMismatch) error {
+	if mismatch.Field == FieldPartitions {
+		response, err := a.client.CreatePartitions(ctx, &kafka.CreatePartitionsRequest{
+			Topics: []kafka.TopicPartitionsConfig{{Name: spec.Name, Count: int32(spec.Partitions)}},
+		})
+		if err == nil {
+			err = response.Errors[spec.Name]
+		}
+		if err != nil {
+			return fmt.Errorf("erro ao aumentar as partições do tópico %s: %w", spec.Name, err)
+		}
+		return nil
+	}
+
+	response, err := a.client.IncrementalAlterConfigs(ctx, &kafka.IncrementalAlterConfigsRequest{
+		Resources: []kafka.IncrementalAlterConfigsRequestResource{{
+			ResourceType: kafka.ResourceTypeTopic,
+			ResourceName: spec.Name,
+			Configs: []kafka.IncrementalAlterConfigsRequestConfig{{
+				Name:            mismatch.Field,
+				Value:           mismatch.Expected,
+				ConfigOperation: kafka.ConfigOperationSet,
+			}},
+		}},
+	})
+	if err == nil {
+		for _, resource := range response.Resources {
+			if resource.Error != nil {
+				err = resource.Error
+			}
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("erro ao alterar %s do tópico %s: %w", mismatch.Field, spec.Name, err)
+	}
+	return nil
+}
+
+// describeConfigs lê retention.ms e cleanup.policy dos tópicos informados
+func (a *TopicAdmin) describeConfigs(ctx context.Context, topics []string) (map[string]map[string]string, error) {
+	configs := make(map[string]map[string]string, len(topics))
+	if len(topics) == 0 {
+		return configs, nil
+	}
+
+	resources := make([]kafka.DescribeConfigRequestResource, len(topics))
+	for i, topic := range topics {
+		resources[i] = kafka.DescribeConfigRequestResource{
+			ResourceType: kafka.ResourceTypeTopic,
+			ResourceName: topic,
+			ConfigNames:  []string{configRetention, configCleanupPolicy},
+		}
+	}
+
+	response, err := a.client.DescribeConfigs(ctx, &kafka.DescribeConfigsRequest{Resources: resources})
+	if err != nil {
+		return nil, fmt.Errorf("erro ao consultar a configuração dos tópicos: %w", err)
+	}
+	for _, resource := range response.Resources {
+		if resource.Error != nil {
+			return nil, fmt.Errorf("erro ao consultar a configuração do tópico %s: %w", resource.ResourceName, resource.Error)
+		}
+		values := make(map[string]string, len(resource.ConfigEntries))
+		for _, entry := range resource.ConfigEntries {
+			values[entry.ConfigName] = entry.ConfigValue
+		}
+		configs[resource.ResourceName] = values
+	}
+	return configs, nil
+}
+
+// compareTopic compara o tópico existente com a especificação
+func compareTopic(spec TopicSpec, topic kafka.Topic, configs map[string]string) []TopicMismatch {
+	var mismatches []TopicMismatch
+
+	if partitions := len(topic.Partitions); partitions != spec.Partitions {
+		mismatches = append(mismatches, TopicMismatch{
+			Topic:    spec.Name,
+			Field:    FieldPartitions,
+			Expected: strconv.Itoa(spec.Partitions),
+			Actual:   strconv.Itoa(partitions),
+			Fixable:  partitions < spec.Partitions, // Partições não podem ser removidas
+		})
+	}
+
+	if len(topic.Partitions) > 0 {
+		if replicas := len(topic.Partitions[0].Replicas); replicas != spec.ReplicationFactor {
+			mismatches = append(mismatches, TopicMismatch{
+				Topic:    spec.Name,
+				Field:    FieldReplicationFactor,
+				Expected: strconv.Itoa(spec.ReplicationFactor),
+				Actual:   strconv.Itoa(replicas),
+			})
+		}
+	}
+
+	if spec.Retention != 0 {
+		if expected := retentionMs(spec.Retention); configs[configRetention] != expected {
+			mismatches = append(mismatches, TopicMismatch{
+				Topic:    spec.Name,
+				Field:    FieldRetention,
+				Expected: expected,
+				Actual:   configs[configRetention],
+				Fixable:  true,
+			})
+		}
+	}
+
+	if expected := cleanupPolicy(spec); configs[configCleanupPolicy] != expected {
+		mismatches = append(mismatches, TopicMismatch{
+			Topic:    spec.Name,
+			Field:    FieldCleanupPolicy,
+			Expected: expected,
+			Actual:   configs[configCleanupPolicy],
+			Fixable:  true,
+		})
+	}
+
+	return mismatches
+}
+
+// topicConfigEntries configurações aplicadas na criação do tópico
+func topicConfigEntries(spec TopicSpec) []kafka.ConfigEntry {
+	entries := []kafka.ConfigEntry{{ConfigName: configCleanupPolicy, ConfigValue: cleanupPolicy(spec)}}
+	if spec.Retention != 0 {
+		entries = append(entries, kafka.ConfigEntry{ConfigName: configRetention, ConfigValue: retentionMs(spec.Retention)})
+	}
+	return entries
+}
+
+// retentionMs converte a retenção no valor de retention.ms
+func retentionMs(retention time.Duration) string {
+	if retention < 0 {
+		return "-1"
+	}
+	return strconv.FormatInt(retention.Milliseconds(), 10)
+}
+
+// cleanupPolicy retorna o cleanup.policy da especificação
+func cleanupPolicy(spec TopicSpec) string {
+	if spec.Compacted {
+		return "compact"
+	}
+	return "delete"
+}
@@ -0,0 +1,247 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/protocol"
+	"github.com/segmentio/kafka-go/protocol/createtopics"
+	"github.com/segmentio/kafka-go/protocol/describeconfigs"
+	"github.com/segmentio/kafka-go/protocol/metadata"
+)
+
+// fakeTopic tópico existente no fakeCluster
+type fakeTopic struct {
+	partitions int
+	replicas   int
+	configs    map[string]string
+}
+
+// fakeCluster responde às requisições administrativas usadas pelo TopicAdmin a partir de um
+// conjunto de tópicos em memória
+type fakeCluster struct {
+	topics  map[string]fakeTopic
+	created []string
+}
+
+func (c *fakeCluster) RoundTrip(ctx context.Context, addr net.Addr, request protocol.Message) (protocol.Message, error) {
+	switch request := request.(type) {
+	case *metadata.Request:
+		response := &metadata.Response{Brokers: []metadata.ResponseBroker{{NodeID: 1, Host: "localhost", Port: 9092}}}
+		for _, name := range request.TopicNames {
+			topic, ok := c.topics[name]
+			if !ok {
+				response.Topics = append(response.Topics, metadata.ResponseTopic{Name: name, ErrorCode: int16(kafka.UnknownTopicOrPartition)})
+				continue
+			}
+			responseTopic := metadata.ResponseTopic{Name: name}
+			for i := 0; i < topic.partitions; i++ {
+				replicas := make([]int32, topic.replicas)
+				for r := range replicas {
+					replicas[r] = int32(r + 1)
+				}
+				responseTopic.Partitions = append(responseTopic.Partitions, metadata.ResponsePartition{
+					PartitionIndex: int32(i),
+					LeaderID:       1,
+					ReplicaNodes:   replicas,
+					IsrNodes:       replicas,
+				})
+			}
+			response.Topics = append(response.Topics, responseTopic)
+		}
+		return response, nil
+
+	case *describeconfigs.Request:
+		response := &describeconfigs.Response{}
+		for _, resource := range request.Resources {
+			responseResource := describeconfigs.ResponseResource{ResourceType: resource.ResourceType, ResourceName: resource.ResourceName}
+			for _, name := range resource.ConfigNames {
+				responseResource.ConfigEntries = append(responseResource.ConfigEntries, describeconfigs.ResponseConfigEntry{
+					ConfigName:  name,
+					ConfigValue: c.topics[resource.ResourceName].configs[name],
+				})
+			}
+			response.Resources = append(response.Resources, responseResource)
+		}
+		return response, nil
+
+	case *createtopics.Request:
+		response := &createtopics.Response{}
+		for _, topic := range request.Topics {
+			if _, ok := c.topics[topic.Name]; ok {
+				response.Topics = append(response.Topics, createtopics.ResponseTopic{Name: topic.Name, ErrorCode: int16(kafka.TopicAlreadyExists)})
+				continue
+			}
+			configs := make(map[string]string)
+			for _, config := range topic.Configs {
+				configs[config.Name] = config.Value
+			}
+			c.topics[topic.Name] = fakeTopic{partitions: int(topic.NumPartitions), replicas: int(topic.ReplicationFactor), configs: configs}
+			c.created = append(c.created, topic.Name)
+			response.Topics = append(response.Topics, createtopics.ResponseTopic{Name: topic.Name})
+		}
+		return response, nil
+	}
+	return nil, fmt.Errorf("requisição não suportada: %T", request)
+}
+
+// newTestTopicAdmin cria um TopicAdmin conectado ao fakeCluster
+func newTestTopicAdmin(cluster *fakeCluster) *TopicAdmin {
+	return &TopicAdmin{client: &kafka.Client{Addr: kafka.TCP("localhost:9092"), Transport: cluster}}
+}
+
+// inSyncTopic tópico existente conforme a especificação de order.created usada nos testes
+func inSyncTopic() fakeTopic {
+	return fakeTopic{partitions: 3, replicas: 1, configs: map[string]string{
+		configRetention:     "604800000",
+		configCleanupPolicy: "delete",
+	}}
+}
+
+func TestTopicAdminPlanAndReconcile(t *testing.T) {
+	registry := NewTopicRegistry()
+	registry.Register(
+		TopicSpec{Name: "order.created", Partitions: 3, ReplicationFactor: 1, Retention: 7 * 24 * time.Hour},
+		TopicSpec{Name: "order.created.dlq", Partitions: 3, ReplicationFactor: 1, Retention: RetentionForever},
+	)
+	dlqInSync := fakeTopic{partitions: 3, replicas: 1, configs: map[string]string{configRetention: "-1", configCleanupPolicy: "delete"}}
+
+	withConfig := func(name, value string) fakeTopic {
+		topic := inSyncTopic()
+		topic.configs = map[string]string{configRetention: topic.configs[configRetention], configCleanupPolicy: topic.configs[configCleanupPolicy]}
+		topic.configs[name] = value
+		return topic
+	}
+
+	tests := []struct {
+		name           string
+		existing       map[string]fakeTopic
+		wantMissing    []string
+		wantInSync     []string
+		wantMismatches []string // <tópico> <campo> fixable=<bool>
+		wantCreated    []string
+	}{
+		{
+			name:        "cluster vazio cria todos",
+			existing:    map[string]fakeTopic{},
+			wantMissing: []string{"order.created", "order.created.dlq"},
+			wantCreated: []string{"order.created", "order.created.dlq"},
+		},
+		{
+			name:        "cria apenas os ausentes",
+			existing:    map[string]fakeTopic{"order.created": inSyncTopic()},
+			wantMissing: []string{"order.created.dlq"},
+			wantInSync:  []string{"order.created"},
+			wantCreated: []string{"order.created.dlq"},
+		},
+		{
+			name:       "tudo conforme",
+			existing:   map[string]fakeTopic{"order.created": inSyncTopic(), "order.created.dlq": dlqInSync},
+			wantInSync: []string{"order.created", "order.created.dlq"},
+		},
+		{
+			name: "menos partições é corrigível",
+			existing: map[string]fakeTopic{
+				"order.created":     {partitions: 1, replicas: 1, configs: inSyncTopic().configs},
+				"order.created.dlq": dlqInSync,
+			},
+			wantInSync:     []string{"order.created.dlq"},
+			wantMismatches: []string{"order.created partitions fixable=true"},
+		},
+		{
+			name: "mais partições ou outro fator de replicação não são corrigíveis",
+			existing: map[string]fakeTopic{
+				"order.created":     {partitions: 6, replicas: 3, configs: inSyncTopic().configs},
+				"order.created.dlq": dlqInSync,
+			},
+			wantInSync:     []string{"order.created.dlq"},
+			wantMismatches: []string{"order.created partitions fixable=false", "order.created replication_factor fixable=false"},
+		},
+		{
+			name: "retenção e cleanup.policy divergentes",
+			existing: map[string]fakeTopic{
+				"order.created":     withConfig(configRetention, "86400000"),
+				"order.created.dlq": {partitions: 3, replicas: 1, configs: map[string]string{configRetention: "-1", configCleanupPolicy: "compact"}},
+			},
+			wantMismatches: []string{"order.created retention.ms fixable=true", "order.created.dlq cleanup.policy fixable=true"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cluster := &fakeCluster{topics: tt.existing}
+			admin := newTestTopicAdmin(cluster)
+
+			plan, err := admin.Plan(context.Background(), registry)
+			if err != nil {
+				t.Fatalf("Plan: %v", err)
+			}
+			assertNames(t, "ausentes", specNames(plan.Missing), tt.wantMissing)
+			assertNames(t, "conformes", plan.InSync, tt.wantInSync)
+			assertNames(t, "divergências", mismatchNames(plan.Mismatches), tt.wantMismatches)
+			if len(cluster.created) > 0 {
+				t.Fatalf("Plan criou tópicos: %v", cluster.created)
+			}
+
+			_, err = admin.Reconcile(context.Background(), registry)
+			var mismatchErr *TopicMismatchError
+			switch {
+			case len(tt.wantMismatches) == 0 && err != nil:
+				t.Fatalf("Reconcile: %v", err)
+			case len(tt.wantMismatches) > 0 && !errors.As(err, &mismatchErr):
+				t.Fatalf("Reconcile() erro = %v, esperado TopicMismatchError", err)
+			case mismatchErr != nil:
+				assertNames(t, "divergências do erro", mismatchNames(mismatchErr.Mismatches), tt.wantMismatches)
+			}
+			assertNames(t, "criados", cluster.created, tt.wantCreated)
+
+			// Os tópicos criados seguem a especificação e a reconciliação seguinte não faz nada
+			plan, err = admin.Plan(context.Background(), registry)
+			if err != nil {
+				t.Fatalf("Plan: %v", err)
+			}
+			if len(plan.Missing) > 0 {
+				t.Errorf("ausentes após Reconcile: %v", specNames(plan.Missing))
+			}
+			assertNames(t, "divergências após Reconcile", mismatchNames(plan.Mismatches), tt.wantMismatches)
+		})
+	}
+}
+
+// specNames nomes das especificações
+func specNames(specs []TopicSpec) []string {
+	names := make([]string, len(specs))
+	for i, spec := range specs {
+		names[i] = spec.Name
+	}
+	return names
+}
+
+// mismatchNames descrição resumida das divergências
+func mismatchNames(mismatches []TopicMismatch) []string {
+	names := make([]string, len(mismatches))
+	for i, mismatch := range mismatches {
+		names[i] = fmt.Sprintf("%s %s fixable=%v", mismatch.Topic, mismatch.Field, mismatch.Fixable)
+	}
+	return names
+}
+
+// assertNames compara as listas sem considerar a ordem
+func assertNames(t *testing.T, what string, got, want []string) {
+	t.Helper()
+
+	got = append([]string(nil), got...)
+	want = append([]string(nil), want...)
+	sort.Strings(got)
+	sort.Strings(want)
+	if strings.Join(got, ", ") != strings.Join(want, ", ") {
+		t.Errorf("%s %v, esperado %v", what, got, want)
+	}
+}
@@ -0,0 +1,83 @@
+package kafka
+
+import (
+	"fmt"
+	"strings"
+)
+
+// TopicRule regra de roteamento por event_type. O padrão pode ser exato ("order.paid")
+// ou terminar em "*" para casar por prefixo ("order.*")
+type TopicRule struct {
+	Pattern string
+	Topic   string
+}
+
+// TopicRules regras de roteamento dos eventos da outbox (OUTBOX_TOPIC_PREFIX e OUTBOX_TOPIC_ROUTES).
+// Vale a primeira regra que casar com o event_type; sem regra, o próprio event_type é o tópico. O
+// prefixo (ex: "staging.") é aplicado em ambos os casos.
+type TopicRules struct {
+	Prefix string
+	Rules  []TopicRule
+}
+
+// TopicFor retorna o tópico de destino do event_type
+func (r TopicRules) TopicFor(eventType string) string {
+	for _, rule := range r.Rules {
+		if matchesEventType(rule.Pattern, eventType) {
+			return r.Prefix + rule.Topic
+		}
+	}
+
+	// Mapeamento direto: user.created -> user.created
+	return r.Prefix + eventType
+}
+
+// Topics retorna os tópicos em que as regras podem publicar, já com o prefixo: o destino de cada
+// event_type informado e o destino de todas as regras, sem repetição
+func (r TopicRules) Topics(eventTypes []string) []string {
+	seen := make(map[string]bool)
+	var topics []string
+	add := func(topic string) {
+		if !seen[topic] {
+			seen[topic] = true
+			topics = append(topics, topic)
+		}
+	}
+
+	for _, eventType := range eventTypes {
+		add(r.TopicFor(eventType))
+	}
+	for _, rule := range r.Rules {
+		add(r.Prefix + rule.Topic)
+	}
+	return topics
+}
+
+// matchesEventType verifica se o event_type casa com o padrão da regra
+func matchesEventType(pattern, eventType string) bool {
+	if strings.HasSuffix(pattern, "*") {
+		return strings.HasPrefix(eventType, strings.TrimSuffix(pattern, "*"))
+	}
+	return pattern == eventType
+}
+
+// ParseTopicRules interpreta regras no formato "order.*=orders,user.created=users"
+func ParseTopicRules(spec string) ([]TopicRule, error) {
+	var rules []TopicRule
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		pattern, topic, found := strings.Cut(entry, "=")
+		pattern = strings.TrimSpace(pattern)
+		topic = strings.TrimSpace(topic)
+		if !found || pattern == "" || topic == "" {
+			return nil, fmt.Errorf("regra de tópico inválida: %q (esperado padrão=tópico)", entry)
+		}
+
+		rules = append(rules, TopicRule{Pattern: pattern, Topic: topic})
+	}
+	return rules, nil
+}
@@ -0,0 +1,152 @@
+package kafka
+
+import (
+	"sort"
+	"time"
+)
+
+// RetentionForever retenção sem expiração (retention.ms = -1)
+const RetentionForever time.Duration = -1
+
+// EventTopics tópicos dos eventos de domínio publicados pela outbox e lidos pelos consumidores
+var EventTopics = []string{
+	"user.created",
+	"user.updated",
+	"product.created",
+	"product.updated",
+	"stock.reserved",
+	"stock.released",
+	"order.created",
+	"order.paid",
+	"order.canceled",
+}
+
+// ConsumerGroups consumer groups dos Routers e os tópicos de eventos lidos por cada um. Os tópicos
+// de retry e de DLQ são registrados por grupo (RetryTopic e DLQTopic).
+var ConsumerGroups = map[string][]string{
+	"product-consumer": {
+		"order.created",
+		"order.canceled",
+	},
+	"query-consumer": {
+		"user.created",
+		"product.created",
+		"product.updated",
+		"order.created",
+		"order.paid",
+		"order.canceled",
+		"stock.reserved",
+		"stock.released",
+	},
+}
+
+// TopicSpec especificação declarativa de um tópico
+type TopicSpec struct {
+	Name              string
+	Partitions        int
+	ReplicationFactor int
+	Retention         time.Duration // 0 = padrão do broker (não verificada); RetentionForever = sem expiração
+	Compacted         bool          // cleanup.policy=compact em vez de delete
+}
+
+// TopicDefaults valores usados ao registrar os tópicos de eventos e seus derivados
+type TopicDefaults struct {
+	Partitions        int
+	ReplicationFactor int
+	Retention         time.Duration // Tópicos de eventos e de retry
+	DLQRetention      time.Duration // Tópicos de DLQ (mantidos por mais tempo para análise e replay)
+}
+
+// TopicRegistry conjunto de tópicos esperados no cluster, reconciliado pelo TopicAdmin
+type TopicRegistry struct {
+	specs map[string]TopicSpec
+}
+
+// NewTopicRegistry cria um registry vazio
+func NewTopicRegistry() *TopicRegistry {
+	return &TopicRegistry{specs: make(map[string]TopicSpec)}
+}
+
+// DefaultTopicRegistry registra os tópicos de eventos (EventTopics), com os tópicos de retry e DLQ
+// de cada consumer group que os lê (ConsumerGroups)
+func DefaultTopicRegistry(defaults TopicDefaults, retryDelays []time.Duration) *TopicRegistry {
+	registry := NewTopicRegistry()
+	for _, topic := range EventTopics {
+		registry.RegisterEventTopic(topic, ConsumerGroupsOf(topic), retryDelays, defaults)
+	}
+	return registry
+}
+
+// ConsumerGroupsOf retorna, em ordem alfabética, os consumer groups que leem o tópico de eventos
+func ConsumerGroupsOf(topic string) []string {
+	var groupIDs []string
+	for groupID, topics := range ConsumerGroups {
+		for _, candidate := range topics {
+			if candidate == topic {
+				groupIDs = append(groupIDs, groupID)
+				break
+			}
+		}
+	}
+	sort.Strings(groupIDs)
+	return groupIDs
+}
+
+// Register adiciona (ou substitui, pelo nome) as especificações informadas
+func (r *TopicRegistry) Register(specs ...TopicSpec) {
+	for _, spec := range specs {
+		r.specs[spec.Name] = spec
+	}
+}
+
+// RegisterEventTopic registra o tópico e, para cada consumer group que o lê, um tópico de retry
+// por atraso e a DLQ. Todos têm o mesmo número de partições, para que a chave caia na mesma
+// partição em todos os níveis.
+func (r *TopicRegistry) RegisterEventTopic(topic string, groupIDs []string, retryDelays []time.Duration, defaults TopicDefaults) {
+	r.Register(eventTopicSpec(topic, defaults))
+	for _, groupID := range groupIDs {
+		r.registerGroupTopics(topic, groupID, retryDelays, true, defaults)
+	}
+}
+
+// registerGroupTopics registra os tópicos de retry do consumer group para o tópico e, se dlq for
+// verdadeiro, a DLQ
+func (r *TopicRegistry) registerGroupTopics(topic, groupID string, retryDelays []time.Duration, dlq bool, defaults TopicDefaults) {
+	spec := eventTopicSpec(topic, defaults)
+	for _, tier := range retryTiers(topic, groupID, retryDelays) {
+		spec.Name = tier.Topic
+		r.Register(spec)
+	}
+
+	if dlq {
+		spec.Name = DLQTopic(topic, groupID)
+		spec.Retention = defaults.DLQRetention
+		r.Register(spec)
+	}
+}
+
+// eventTopicSpec especificação de um tópico de eventos (e dos seus tópicos de retry)
+func eventTopicSpec(topic string, defaults TopicDefaults) TopicSpec {
+	return TopicSpec{
+		Name:              topic,
+		Partitions:        defaults.Partitions,
+		ReplicationFactor: defaults.ReplicationFactor,
+		Retention:         defaults.Retention,
+	}
+}
+
+// Lookup retorna a especificação do tópico, se registrada
+func (r *TopicRegistry) Lookup(name string) (TopicSpec, bool) {
+	spec, ok := r.specs[name]
+	return spec, ok
+}
+
+// Specs retorna as especificações em ordem alfabética
+func (r *TopicRegistry) Specs() []TopicSpec {
+	specs := make([]TopicSpec, 0, len(r.specs))
+	for _, spec := range r.specs {
+		specs = append(specs, spec)
+	}
+	sort.Slice(specs, func(i, j int) bool { return specs[i].Name < specs[j].Name })
+	return specs
+}
@@ -0,0 +1,89 @@
+package kafka
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRegisterEventTopicScopesRetryAndDLQByGroup(t *testing.T) {
+	defaults := TopicDefaults{Partitions: 3, ReplicationFactor: 1, Retention: time.Hour, DLQRetention: RetentionForever}
+	registry := NewTopicRegistry()
+	registry.RegisterEventTopic("order.created", []string{"product-consumer", "query-consumer"}, []time.Duration{5 * time.Second}, defaults)
+
+	want := map[string]time.Duration{
+		"order.created": time.Hour,
+		"order.created.product-consumer.retry.5s": time.Hour,
+		"order.created.product-consumer.dlq":      RetentionForever,
+		"order.created.query-consumer.retry.5s":   time.Hour,
+		"order.created.query-consumer.dlq":        RetentionForever,
+	}
+	specs := registry.Specs()
+	if len(specs) != len(want) {
+		t.Fatalf("%d tópicos registrados, esperado %d: %v", len(specs), len(want), specs)
+	}
+	for _, spec := range specs {
+		retention, ok := want[spec.Name]
+		if !ok {
+			t.Errorf("tópico inesperado %s", spec.Name)
+			continue
+		}
+		if spec.Retention != retention || spec.Partitions != 3 {
+			t.Errorf("%s com retenção %s e %d partições, esperado %s e 3", spec.Name, spec.Retention, spec.Partitions, retention)
+		}
+	}
+}
+
+func TestDefaultTopicRegistryCoversConsumerGroups(t *testing.T) {
+	registry := DefaultTopicRegistry(TopicDefaults{Partitions: 3, ReplicationFactor: 1}, DefaultRetryDelays)
+
+	for groupID, topics := range ConsumerGroups {
+		for _, topic := range topics {
+			names := []string{DLQTopic(topic, groupID)}
+			for _, delay := range DefaultRetryDelays {
+				names = append(names, RetryTopic(topic, groupID, delay))
+			}
+			for _, name := range names {
+				if _, ok := registry.Lookup(name); !ok {
+					t.Errorf("%s não registrado", name)
+				}
+			}
+		}
+	}
+	// Tópicos sem consumer group não ganham retry nem DLQ
+	if _, ok := registry.Lookup(DLQTopic("user.updated", "query-consumer")); ok {
+		t.Error("DLQ registrada para um tópico que o grupo não lê")
+	}
+}
+
+func TestRouterRegisterTopicsUsesRouteOptions(t *testing.T) {
+	router := NewRouterWithBroker(nil, "query-consumer", nil)
+	router.SetRetryDelays([]time.Duration{5 * time.Second})
+	router.Handle("order.created", func(ctx context.Context, message []byte) error { return nil })
+	router.HandleWithOptions("order.paid", func(ctx context.Context, message []byte) error { return nil },
+		RouteOptions{RetryDelays: []time.Duration{30 * time.Second, 10 * time.Minute}})
+	router.HandleWithOptions("stock.released", func(ctx context.Context, message []byte) error { return nil },
+		RouteOptions{RetryDelays: []time.Duration{}, DisableDLQ: true})
+
+	registry := NewTopicRegistry()
+	router.RegisterTopics(registry, TopicDefaults{Partitions: 3, ReplicationFactor: 1})
+
+	var got []string
+	for _, spec := range registry.Specs() {
+		got = append(got, spec.Name)
+	}
+	want := []string{
+		"order.created",
+		"order.created.query-consumer.dlq",
+		"order.created.query-consumer.retry.5s",
+		"order.paid",
+		"order.paid.query-consumer.dlq",
+		"order.paid.query-consumer.retry.10m",
+		"order.paid.query-consumer.retry.30s",
+		"stock.released",
+	}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("tópicos registrados %v, esperado %v", got, want)
+	}
+}
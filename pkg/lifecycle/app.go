@@ -0,0 +1,220 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"go.mongodb.org/mongo-driver/mongo"
+	"gorm.io/gorm"
+)
+
+// CloseFunc libera um recurso no desligamento, respeitando o prazo do contexto quando possível
+type CloseFunc func(ctx context.Context) error
+
+// App coordena o ciclo de vida de um serviço. Ao receber SIGINT/SIGTERM (ou se um componente
+// falhar), encerra em etapas dentro do prazo configurado:
+//  1. servidores HTTP param de aceitar conexões e terminam as requisições em andamento
+//  2. o contexto dos componentes é cancelado e Wait aguarda que terminem o trabalho atual
+//     (consumidores confirmam a mensagem em processamento, o dispatcher conclui o lote)
+//  3. os recursos registrados em OnClose são liberados na ordem inversa do registro
+type App struct {
+	shutdownTimeout time.Duration
+	ctx             context.Context
+	cancel          context.CancelFunc
+	workers         sync.WaitGroup
+	servers         []*http.Server
+	closers         []namedCloser
+	errs            chan error
+}
+
+// namedCloser recurso liberado no desligamento
+type namedCloser struct {
+	name  string
+	close CloseFunc
+}
+
+// New cria o ciclo de vida com o prazo total de desligamento informado
+func New(shutdownTimeout time.Duration) *App {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &App{
+		shutdownTimeout: shutdownTimeout,
+		ctx:             ctx,
+		cancel:          cancel,
+		errs:            make(chan error, 1),
+	}
+}
+
+// Context retorna o contexto cancelado no início do desligamento dos componentes
+func (a *App) Context() context.Context {
+	return a.ctx
+}
+
+// Go executa um componente em background. Um erro diferente do cancelamento inicia o
+// desligamento do serviço.
+func (a *App) Go(name string, run func(ctx context.Context) error) {
+	a.workers.Add(1)
+	go func() {
+		defer a.workers.Done()
+		if err := run(a.ctx); err != nil && !errors.Is(err, context.Canceled) {
+			a.fail(fmt.Errorf("%s: %w", name, err))
+			return
+		}
+		log.Info().Str("component", name).Msg("componente finalizado")
+	}()
+}
+
+// Start executa em background um componente que roda até o contexto ser cancelado
+// (ex: OutboxDispatcher.Start)
+func (a *App) Start(name string, start func(ctx context.Context)) {
+	a.Go(name, func(ctx context.Context) error {
+		start(ctx)
+		return nil
+	})
+}
+
+// Serve inicia um servidor HTTP, encerrado com Shutdown no desligamento
+func (a *App) Serve(name, addr string, handler http.Handler) {
+	server := &http.Server{
+		Addr:    addr,
+		Handler: handler,
+	}
+	a.servers = append(a.servers, server)
+
+	go func() {
+		log.Info().Str("server", name).Str("addr", addr).Msg("servidor HTTP iniciado")
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			a.fail(fmt.Errorf("%s: %w", name, err))
+		}
+	}()
+}
+
+// OnClose registra um recurso a ser liberado depois que os componentes terminarem. Os recursos
+// são liberados na ordem inversa do registro, como defer.
+func (a *App) OnClose(name string, close CloseFunc) {
+	a.closers = append(a.closers, namedCloser{name: name, close: close})
+}
+
+// fail registra o primeiro erro fatal de um componente
+func (a *App) fail(err error) {
+	select {
+	case a.errs <- err:
+	default:
+	}
+}
+
+// Wait bloqueia até SIGINT/SIGTERM ou até um componente falhar e então executa o desligamento.
+// Retorna o erro do componente que falhou ou o erro do desligamento, se houver.
+func (a *App) Wait() error {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	var cause error
+	select {
+	case sig := <-signals:
+		log.Info().Str("signal", sig.String()).Msg("sinal recebido, iniciando desligamento")
+	case cause = <-a.errs:
+		log.Error().Err(cause).Msg("componente falhou, iniciando desligamento")
+	}
+
+	// Um segundo sinal interrompe o desligamento imediatamente
+	go func() {
+		sig := <-signals
+		log.Warn().Str("signal", sig.String()).Msg("segundo sinal recebido, encerrando sem aguardar")
+		os.Exit(1)
+	}()
+
+	if err := a.shutdown(); err != nil {
+		log.Error().Err(err).Msg("desligamento incompleto")
+		if cause == nil {
+			cause = err
+		}
+	} else {
+		log.Info().Msg("desligamento concluído")
+	}
+	return cause
+}
+
+// shutdown encerra servidores, componentes e recursos, nessa ordem, dentro do prazo
+func (a *App) shutdown() error {
+	ctx, cancel := context.WithTimeout(context.Background(), a.shutdownTimeout)
+	defer cancel()
+
+	var errs []error
+
+	// 1. Para de aceitar requisições e aguarda as que estão em andamento
+	for _, server := range a.servers {
+		if err := server.Shutdown(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("erro ao encerrar servidor HTTP %s: %w", server.Addr, err))
+		}
+	}
+
+	// 2. Cancela os componentes e aguarda que concluam o trabalho em andamento
+	a.cancel()
+	drained := make(chan struct{})
+	go func() {
+		a.workers.Wait()
+		close(drained)
+	}()
+	select {
+	case <-drained:
+	case <-ctx.Done():
+		errs = append(errs, fmt.Errorf("componentes não terminaram dentro de %s", a.shutdownTimeout))
+	}
+
+	// 3. Libera os recursos, do último registrado para o primeiro
+	for i := len(a.closers) - 1; i >= 0; i-- {
+		closer := a.closers[i]
+		done := make(chan error, 1)
+		go func() {
+			done <- closer.close(ctx)
+		}()
+
+		select {
+		case err := <-done:
+			if err != nil {
+				errs = append(errs, fmt.Errorf("erro ao fechar %s: %w", closer.name, err))
+			} else {
+				log.Info().Str("resource", closer.name).Msg("recurso fechado")
+			}
+		case <-ctx.Done():
+			errs = append(errs, fmt.Errorf("prazo de desligamento esgotado ao fechar %s", closer.name))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Closer adapta um io.Closer (ex: *kafka.Producer) para OnClose
+func Closer(closer io.Closer) CloseFunc {
+	return func(ctx context.Context) error {
+		return closer.Close()
+	}
+}
+
+// CloseGorm fecha o pool de conexões do banco usado pelo GORM
+func CloseGorm(db *gorm.DB) CloseFunc {
+	return func(ctx context.Context) error {
+		sqlDB, err := db.DB()
+		if err != nil {
+			return err
+		}
+		return sqlDB.Close()
+	}
+}
+
+// CloseMongo desconecta o cliente MongoDB dentro do prazo do desligamento
+func CloseMongo(client *mongo.Client) CloseFunc {
+	return func(ctx context.Context) error {
+		return client.Disconnect(ctx)
+	}
+}
@@ -0,0 +1,186 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recorder registra a ordem dos eventos do desligamento
+type recorder struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (r *recorder) add(event string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+}
+
+func (r *recorder) String() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return strings.Join(r.events, " ")
+}
+
+// closer recurso que registra quando é fechado
+func (r *recorder) closer(name string, err error) CloseFunc {
+	return func(ctx context.Context) error {
+		r.add("close:" + name)
+		return err
+	}
+}
+
+func TestShutdownOrder(t *testing.T) {
+	closeErr := errors.New("conexão já fechada")
+
+	tests := []struct {
+		name      string
+		closers   []string
+		failing   string // Recurso cujo fechamento falha
+		want      string
+		wantError string
+	}{
+		{
+			name:    "recursos na ordem inversa do registro",
+			closers: []string{"db", "producer", "reader"},
+			want:    "worker close:reader close:producer close:db",
+		},
+		{
+			name:      "erro em um recurso não impede os demais",
+			closers:   []string{"db", "producer"},
+			failing:   "producer",
+			want:      "worker close:producer close:db",
+			wantError: "erro ao fechar producer: conexão já fechada",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events := &recorder{}
+			app := New(time.Second)
+
+			// O componente conclui o trabalho em andamento depois do cancelamento
+			app.Start("worker", func(ctx context.Context) {
+				<-ctx.Done()
+				time.Sleep(20 * time.Millisecond)
+				events.add("worker")
+			})
+			for _, name := range tt.closers {
+				var err error
+				if name == tt.failing {
+					err = closeErr
+				}
+				app.OnClose(name, events.closer(name, err))
+			}
+
+			err := app.shutdown()
+			if got := errorString(err); got != tt.wantError {
+				t.Errorf("shutdown() erro %q, esperado %q", got, tt.wantError)
+			}
+			if got := events.String(); got != tt.want {
+				t.Errorf("ordem %q, esperado %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestShutdownWaitsForInFlightRequests(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := listener.Addr().String()
+	listener.Close()
+
+	events := &recorder{}
+	started := make(chan struct{})
+	app := New(5 * time.Second)
+	app.Serve("api", addr, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		time.Sleep(100 * time.Millisecond)
+		events.add("request")
+	}))
+	app.Start("worker", func(ctx context.Context) {
+		<-ctx.Done()
+		events.add("worker")
+	})
+	app.OnClose("db", events.closer("db", nil))
+
+	go func() {
+		for {
+			response, err := http.Get("http://" + addr)
+			if err == nil {
+				response.Body.Close()
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}()
+
+	select {
+	case <-started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("requisição não chegou ao servidor")
+	}
+
+	if err := app.shutdown(); err != nil {
+		t.Fatalf("shutdown: %v", err)
+	}
+	if got, want := events.String(), "request worker close:db"; got != want {
+		t.Errorf("ordem %q, esperado %q", got, want)
+	}
+}
+
+func TestShutdownTimeout(t *testing.T) {
+	app := New(50 * time.Millisecond)
+	release := make(chan struct{})
+	defer close(release)
+
+	// Componente que ignora o cancelamento
+	app.Start("stuck", func(ctx context.Context) {
+		<-release
+	})
+
+	err := app.shutdown()
+	if err == nil || !strings.Contains(err.Error(), "componentes não terminaram") {
+		t.Errorf("shutdown() erro %v, esperado prazo esgotado", err)
+	}
+}
+
+func TestWaitReturnsComponentFailure(t *testing.T) {
+	componentErr := errors.New("conexão com o broker perdida")
+	events := &recorder{}
+
+	app := New(time.Second)
+	app.Go("consumer", func(ctx context.Context) error {
+		return componentErr
+	})
+	app.Start("dispatcher", func(ctx context.Context) {
+		<-ctx.Done()
+		events.add("dispatcher")
+	})
+	app.OnClose("db", events.closer("db", nil))
+
+	err := app.Wait()
+	if !errors.Is(err, componentErr) {
+		t.Errorf("Wait() erro %v, esperado %v", err, componentErr)
+	}
+	if got, want := events.String(), "dispatcher close:db"; got != want {
+		t.Errorf("ordem %q, esperado %q", got, want)
+	}
+}
+
+// errorString retorna a mensagem do erro ou "" para nil
+func errorString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
@@ -0,0 +1,262 @@
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+	"pkg/outbox/dispatcher"
+	"pkg/outbox/entities"
+	"pkg/outbox/repository"
+	pkgoutboxservices "pkg/outbox/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultListLimit = 50
+	maxListLimit     = 500
+)
+
+// MessageResponse representação de uma mensagem da outbox para a API administrativa
+type MessageResponse struct {
+	ID            uint            `json:"id"`
+	Aggregate     string          `json:"aggregate"`
+	AggregateID   string          `json:"aggregate_id,omitempty"`
+	EventType     string          `json:"event_type"`
+	Status        string          `json:"status"`
+	Attempts      int             `json:"attempts"`
+	LastError     *string         `json:"last_error,omitempty"`
+	NextAttemptAt *time.Time      `json:"next_attempt_at,omitempty"`
+	ClaimedBy     *string         `json:"claimed_by,omitempty"`
+	ClaimedUntil  *time.Time      `json:"claimed_until,omitempty"`
+	CreatedAt     time.Time       `json:"created_at"`
+	ProcessedAt   *time.Time      `json:"processed_at,omitempty"`
+	Payload       json.RawMessage `json:"payload,omitempty"`
+	Headers       json.RawMessage `json:"headers,omitempty"`
+}
+
+// OutboxAdminController controller para inspeção e manutenção da outbox
+type OutboxAdminController struct {
+	outboxService pkgoutboxservices.OutboxService
+	dispatcher    dispatcher.OutboxDispatcher // Opcional: nil em serviços que só escrevem na outbox
+}
+
+// NewOutboxAdminController cria um novo controller administrativo da outbox
+func NewOutboxAdminController(outboxService pkgoutboxservices.OutboxService, outboxDispatcher dispatcher.OutboxDispatcher) *OutboxAdminController {
+	return &OutboxAdminController{
+		outboxService: outboxService,
+		dispatcher:    outboxDispatcher,
+	}
+}
+
+// ListMessages lista mensagens filtrando por status, aggregate e event_type
+func (c *OutboxAdminController) ListMessages(ctx *gin.Context) {
+	limit, err := queryInt(ctx, "limit", defaultListLimit)
+	if err != nil || limit <= 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Parâmetro limit inválido"})
+		return
+	}
+	if limit > maxListLimit {
+		limit = maxListLimit
+	}
+
+	offset, err := queryInt(ctx, "offset", 0)
+	if err != nil || offset < 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Parâmetro offset inválido"})
+		return
+	}
+
+	filter := repository.OutboxFilter{
+		Status:    ctx.Query("status"),
+		Aggregate: ctx.Query("aggregate"),
+		EventType: ctx.Query("event_type"),
+		Limit:     limit,
+		Offset:    offset,
+	}
+
+	messages, err := c.outboxService.ListMessages(ctx.Request.Context(), filter)
+	if err != nil {
+		log.Error().Err(err).Msg("erro ao listar mensagens da outbox")
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Erro interno do servidor"})
+		return
+	}
+
+	responses := make([]MessageResponse, len(messages))
+	for i := range messages {
+		// Na listagem o payload é omitido para manter a resposta enxuta
+		responses[i] = toMessageResponse(&messages[i], false)
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data":   responses,
+		"total":  len(responses),
+		"limit":  limit,
+		"offset": offset,
+	})
+}
+
+// GetMessage obtém uma mensagem por ID, incluindo payload e headers
+func (c *OutboxAdminController) GetMessage(ctx *gin.Context) {
+	id, ok := parseID(ctx)
+	if !ok {
+		return
+	}
+
+	message, err := c.outboxService.GetMessageByID(ctx.Request.Context(), id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Mensagem não encontrada"})
+			return
+		}
+		log.Error().Err(err).Uint("message_id", id).Msg("erro ao buscar mensagem da outbox")
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Erro interno do servidor"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": toMessageResponse(message, true)})
+}
+
+// RequeueMessage devolve uma mensagem FAILED ou DEAD para a fila de publicação
+func (c *OutboxAdminController) RequeueMessage(ctx *gin.Context) {
+	id, ok := parseID(ctx)
+	if !ok {
+		return
+	}
+
+	message, err := c.outboxService.GetMessageByID(ctx.Request.Context(), id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Mensagem não encontrada"})
+			return
+		}
+		log.Error().Err(err).Uint("message_id", id).Msg("erro ao buscar mensagem da outbox")
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Erro interno do servidor"})
+		return
+	}
+
+	if message.Status != entities.StatusFailed && message.Status != entities.StatusDead {
+		ctx.JSON(http.StatusConflict, gin.H{"error": "Apenas mensagens FAILED ou DEAD podem ser reenfileiradas", "status": message.Status})
+		return
+	}
+
+	requeued, err := c.outboxService.RequeueMessage(ctx.Request.Context(), id)
+	if err != nil {
+		log.Error().Err(err).Uint("message_id", id).Msg("erro ao reenfileirar mensagem da outbox")
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Erro interno do servidor"})
+		return
+	}
+
+	if !requeued {
+		// O status mudou entre a leitura e a atualização
+		ctx.JSON(http.StatusConflict, gin.H{"error": "Mensagem não está mais em status reenfileirável"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Mensagem reenfileirada com sucesso"})
+}
+
+// RequeueByStatus devolve para a fila todas as mensagens FAILED ou DEAD (?status=DEAD)
+func (c *OutboxAdminController) RequeueByStatus(ctx *gin.Context) {
+	status := ctx.Query("status")
+	if status != entities.StatusFailed && status != entities.StatusDead {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Parâmetro status deve ser FAILED ou DEAD"})
+		return
+	}
+
+	count, err := c.outboxService.RequeueMessagesByStatus(ctx.Request.Context(), status)
+	if err != nil {
+		log.Error().Err(err).Str("status", status).Msg("erro ao reenfileirar mensagens da outbox")
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Erro interno do servidor"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"requeued": count,
+		"message":  "Mensagens reenfileiradas com sucesso",
+	})
+}
+
+// GetStats retorna estatísticas da outbox e, se houver, do dispatcher deste serviço
+func (c *OutboxAdminController) GetStats(ctx *gin.Context) {
+	if c.dispatcher != nil {
+		stats, err := c.dispatcher.GetStats(ctx.Request.Context())
+		if err != nil {
+			log.Error().Err(err).Msg("erro ao obter estatísticas do dispatcher")
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Erro interno do servidor"})
+			return
+		}
+		ctx.JSON(http.StatusOK, gin.H{"data": stats})
+		return
+	}
+
+	pendingCount, err := c.outboxService.GetPendingCount(ctx.Request.Context())
+	if err != nil {
+		log.Error().Err(err).Msg("erro ao obter estatísticas da outbox")
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Erro interno do servidor"})
+		return
+	}
+
+	statusCounts, err := c.outboxService.GetStatusCounts(ctx.Request.Context())
+	if err != nil {
+		log.Error().Err(err).Msg("erro ao obter estatísticas da outbox")
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Erro interno do servidor"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": map[string]interface{}{
+		"pending_count": pendingCount,
+		"status_counts": statusCounts,
+	}})
+}
+
+// toMessageResponse converte a entidade em resposta; includeBody inclui payload e headers
+func toMessageResponse(message *entities.OutboxMessage, includeBody bool) MessageResponse {
+	response := MessageResponse{
+		ID:            message.ID,
+		Aggregate:     message.Aggregate,
+		AggregateID:   message.AggregateID,
+		EventType:     message.EventType,
+		Status:        message.Status,
+		Attempts:      message.Attempts,
+		LastError:     message.LastError,
+		NextAttemptAt: message.NextAttemptAt,
+		ClaimedBy:     message.ClaimedBy,
+		ClaimedUntil:  message.ClaimedUntil,
+		CreatedAt:     message.CreatedAt,
+		ProcessedAt:   message.ProcessedAt,
+	}
+
+	if includeBody {
+		response.Payload = json.RawMessage(message.Payload)
+		if message.Headers.Valid {
+			response.Headers = json.RawMessage(message.Headers.String)
+		}
+	}
+
+	return response
+}
+
+// parseID lê o parâmetro :id da rota, respondendo 400 se for inválido
+func parseID(ctx *gin.Context) (uint, bool) {
+	idStr := ctx.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		log.Error().Err(err).Str("id", idStr).Msg("ID inválido")
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// queryInt lê um parâmetro inteiro da query string com valor padrão
+func queryInt(ctx *gin.Context, name string, defaultValue int) (int, error) {
+	value := ctx.Query(name)
+	if value == "" {
+		return defaultValue, nil
+	}
+	return strconv.Atoi(value)
+}
@@ -0,0 +1,17 @@
+package admin
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+// SetupRoutes monta as rotas administrativas da outbox em /admin/outbox
+func SetupRoutes(r gin.IRouter, controller *OutboxAdminController) {
+	outbox := r.Group("/admin/outbox")
+	{
+		outbox.GET("/messages", controller.ListMessages)                // Listar (?status=&aggregate=&event_type=&limit=&offset=)
+		outbox.GET("/messages/:id", controller.GetMessage)              // Obter por ID com payload
+		outbox.POST("/messages/:id/requeue", controller.RequeueMessage) // Reenfileirar FAILED/DEAD
+		outbox.POST("/requeue", controller.RequeueByStatus)             // Reenfileirar em lote (?status=DEAD)
+		outbox.GET("/stats", controller.GetStats)                       // Estatísticas da outbox/dispatcher
+	}
+}
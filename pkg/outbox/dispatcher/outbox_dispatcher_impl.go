@@ -2,12 +2,17 @@ package dispatcher
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
+	"os"
 	"time"
 	"pkg/outbox/entities"
+	pkgoutboxmetrics "pkg/outbox/metrics"
 	pkgoutboxservices "pkg/outbox/services"
+	pkgevents "pkg/events"
+	pkgkafka "pkg/kafka"
 
+	"github.com/google/uuid"
 	"github.com/rs/zerolog/log"
 )
 
@@ -15,8 +20,16 @@ import (
 type OutboxDispatcherImpl struct {
 	outboxService pkgoutboxservices.OutboxService
 	producer      Producer
-	interval      time.Duration
+	interval      time.Duration // Intervalo mínimo de polling, usado sob carga
+	maxInterval   time.Duration // Intervalo máximo de polling, atingido quando ocioso
 	batchSize     int
+	instanceID    string        // Identifica este dispatcher nos leases da outbox
+	leaseDuration time.Duration // Tempo que um lote fica reservado para este dispatcher
+	maxAttempts   int           // Tentativas antes de estacionar a mensagem como DEAD
+	baseBackoff   time.Duration // Atraso da primeira nova tentativa
+	maxBackoff    time.Duration // Limite superior do backoff exponencial
+	topicRouter   TopicRouter   // Define o tópico de cada mensagem
+	strictOrder   bool          // Retém mensagens de um agregado enquanto a anterior não for publicada
 }
 
 // NewOutboxDispatcher cria um novo dispatcher
@@ -25,114 +38,318 @@ func NewOutboxDispatcher(outboxService pkgoutboxservices.OutboxService, producer
 		outboxService: outboxService,
 		producer:      producer,
 		interval:      interval,
+		maxInterval:   max(interval, time.Second), // Sem sinal de outro processo, o atraso ocioso fica em até 1s
 		batchSize:     100, // Processa até 100 mensagens por vez
+		instanceID:    newInstanceID(),
+		leaseDuration: 30 * time.Second,
+		maxAttempts:   10,
+		baseBackoff:   time.Second,
+		maxBackoff:    5 * time.Minute,
+		topicRouter:   NewTopicRouter("", nil),
 	}
 }
 
-// Start inicia o dispatcher em background
+// newInstanceID gera um identificador único para o dispatcher (host-pid-sufixo aleatório)
+func newInstanceID() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "unknown"
+	}
+	return fmt.Sprintf("%s-%d-%s", hostname, os.Getpid(), uuid.NewString()[:8])
+}
+
+// Start inicia o dispatcher em background. O polling é adaptativo: drena imediatamente
+// enquanto os lotes vêm cheios, volta ao intervalo mínimo quando há mensagens e dobra até
+// maxInterval quando ocioso. Escritas confirmadas no mesmo processo acordam o dispatcher na hora.
 func (d *OutboxDispatcherImpl) Start(ctx context.Context) {
 	log.Info().
+		Str("instance_id", d.instanceID).
 		Dur("interval", d.interval).
+		Dur("max_interval", d.maxInterval).
 		Int("batch_size", d.batchSize).
+		Dur("lease", d.leaseDuration).
+		Bool("strict_order", d.strictOrder).
 		Msg("iniciando outbox dispatcher")
 
-	ticker := time.NewTicker(d.interval)
-	defer ticker.Stop()
+	currentInterval := d.interval
+	timer := time.NewTimer(currentInterval)
+	defer timer.Stop()
+
+	notifications := d.outboxService.Notifications()
 
 	for {
 		select {
 		case <-ctx.Done():
 			log.Info().Msg("outbox dispatcher interrompido")
 			return
-		case <-ticker.C:
-			if err := d.processPending(ctx); err != nil {
-				log.Error().Err(err).Msg("erro ao processar mensagens pendentes")
+		case <-notifications:
+			// Mensagem nova confirmada neste processo: processa sem esperar o timer
+			if !timer.Stop() {
+				select {
+				case <-timer.C:
+				default:
+				}
 			}
+		case <-timer.C:
+		}
+
+		// O lote iniciado é concluído mesmo que o desligamento comece durante o processamento
+		count, err := d.processPending(context.WithoutCancel(ctx))
+		if err != nil {
+			log.Error().Err(err).Msg("erro ao processar mensagens pendentes")
+		}
+
+		currentInterval = d.nextInterval(currentInterval, count, err)
+		timer.Reset(currentInterval)
+	}
+}
+
+// nextInterval calcula o próximo intervalo de polling a partir do resultado do ciclo
+func (d *OutboxDispatcherImpl) nextInterval(current time.Duration, count int, err error) time.Duration {
+	switch {
+	case err == nil && count >= d.batchSize:
+		// Lote cheio: provavelmente há mais mensagens, drena imediatamente
+		return 0
+	case count > 0:
+		return d.interval
+	default:
+		// Ocioso (ou erro): recua exponencialmente até maxInterval
+		next := current * 2
+		if next < d.interval {
+			next = d.interval
+		}
+		if next > d.maxInterval {
+			next = d.maxInterval
 		}
+		return next
 	}
 }
 
-// processPending processa mensagens pendentes da outbox
-func (d *OutboxDispatcherImpl) processPending(ctx context.Context) error {
-	messages, err := d.outboxService.GetPendingMessages(ctx, d.batchSize)
+// processPending processa mensagens pendentes da outbox e retorna quantas foram reservadas
+func (d *OutboxDispatcherImpl) processPending(ctx context.Context) (int, error) {
+	// Reserva o lote para este dispatcher; outras réplicas recebem mensagens diferentes.
+	// No modo estrito só a mensagem mais antiga de cada agregado é reservada, então uma falha
+	// retém as seguintes do mesmo agregado até ela ser publicada.
+	claim := d.outboxService.ClaimPendingMessages
+	if d.strictOrder {
+		claim = d.outboxService.ClaimPendingMessagesOrdered
+	}
+	messages, err := claim(ctx, d.instanceID, d.batchSize, d.leaseDuration)
 	if err != nil {
-		return fmt.Errorf("erro ao buscar mensagens pendentes: %w", err)
+		return 0, fmt.Errorf("erro ao reservar mensagens pendentes: %w", err)
 	}
 
 	if len(messages) == 0 {
 		log.Debug().Msg("nenhuma mensagem pendente encontrada na outbox")
-		return nil
+		return 0, nil
 	}
 
 	log.Info().
 		Int("count", len(messages)).
 		Msg("processando mensagens da outbox")
 
-	processedCount := 0
+	// Monta o lote; mensagens com headers inválidos falham sem ir ao Kafka
+	batch := make([]pkgkafka.Message, 0, len(messages))
+	batchMessages := make([]entities.OutboxMessage, 0, len(messages))
 	failedCount := 0
 
 	for _, message := range messages {
-		if err := d.processMessage(ctx, message); err != nil {
-			log.Error().
-				Err(err).
-				Uint("message_id", message.ID).
-				Str("event_type", message.EventType).
-				Msg("erro ao processar mensagem da outbox")
+		kafkaMessage, err := d.buildMessage(message)
+		if err != nil {
+			pkgoutboxmetrics.FailuresTotal.WithLabelValues(d.topicRouter.TopicFor(message)).Inc()
+			d.logFailure(message, err)
+			d.handleFailure(ctx, message, err)
 			failedCount++
 			continue
 		}
+		batch = append(batch, kafkaMessage)
+		batchMessages = append(batchMessages, message)
+	}
 
-		// Marca como processada
-		if err := d.outboxService.MarkMessageAsProcessed(ctx, message.ID); err != nil {
-			log.Error().
-				Err(err).
-				Uint("message_id", message.ID).
-				Msg("erro ao marcar mensagem como processada")
+	// Publica o lote inteiro em uma única chamada e separa sucessos de falhas
+	publishErrors := d.publishBatch(ctx, batch)
+
+	publishedAt := time.Now()
+	processedIDs := make([]uint, 0, len(batchMessages))
+	for i, message := range batchMessages {
+		if publishErrors[i] != nil {
+			pkgoutboxmetrics.FailuresTotal.WithLabelValues(batch[i].Topic).Inc()
+			d.logFailure(message, publishErrors[i])
+			d.handleFailure(ctx, message, publishErrors[i])
 			failedCount++
 			continue
 		}
+		pkgoutboxmetrics.PublishedTotal.WithLabelValues(batch[i].Topic).Inc()
+		pkgoutboxmetrics.DeliveryLatency.Observe(publishedAt.Sub(message.CreatedAt).Seconds())
+		processedIDs = append(processedIDs, message.ID)
+	}
 
-		processedCount++
+	// Confirma todas as mensagens publicadas em um único UPDATE
+	processedCount, err := d.outboxService.MarkMessagesAsProcessed(ctx, processedIDs, d.instanceID)
+	if err != nil {
+		// As mensagens voltam a ser reservadas quando o lease expirar (entrega at-least-once)
+		log.Error().
+			Err(err).
+			Int("count", len(processedIDs)).
+			Msg("erro ao marcar mensagens como processadas")
+		failedCount += len(processedIDs)
+	} else if processedCount < int64(len(processedIDs)) {
+		// O lease expirou durante a publicação e outro dispatcher reservou as mensagens restantes
+		log.Warn().
+			Int("count", len(processedIDs)).
+			Int64("updated", processedCount).
+			Msg("lease expirado: mensagens publicadas já reservadas por outro dispatcher")
 	}
 
 	log.Info().
-		Int("processed", processedCount).
+		Int64("processed", processedCount).
 		Int("failed", failedCount).
 		Int("total", len(messages)).
 		Msg("processamento de mensagens concluído")
 
-	return nil
+	return len(messages), nil
 }
 
-// processMessage processa uma mensagem individual
-func (d *OutboxDispatcherImpl) processMessage(ctx context.Context, message entities.OutboxMessage) error {
-	// Determina o tópico baseado no tipo de evento
-	topic := d.getTopicForEvent(message.EventType)
+// publishBatch publica o lote e retorna o erro de cada mensagem (nil = publicada)
+func (d *OutboxDispatcherImpl) publishBatch(ctx context.Context, batch []pkgkafka.Message) []error {
+	publishErrors := make([]error, len(batch))
+	if len(batch) == 0 {
+		return publishErrors
+	}
 
-	// Deserializa o payload
-	var event interface{}
-	if err := json.Unmarshal([]byte(message.Payload), &event); err != nil {
-		return fmt.Errorf("erro ao deserializar payload: %w", err)
+	start := time.Now()
+	err := d.producer.PublishBatch(ctx, batch)
+	pkgoutboxmetrics.PublishDuration.Observe(time.Since(start).Seconds())
+	if err == nil {
+		return publishErrors
 	}
 
-	// Publica no Kafka
-	if err := d.producer.PublishEvent(ctx, topic, event); err != nil {
-		return fmt.Errorf("erro ao publicar evento: %w", err)
+	// Falha parcial: apenas as mensagens com erro serão tentadas novamente
+	var batchErr pkgkafka.BatchError
+	if errors.As(err, &batchErr) && len(batchErr) == len(batch) {
+		for i := range batch {
+			if batchErr[i] != nil {
+				publishErrors[i] = fmt.Errorf("erro ao publicar evento: %w", batchErr[i])
+			}
+		}
+		return publishErrors
 	}
 
-	log.Info().
+	// Falha do lote inteiro
+	for i := range batch {
+		publishErrors[i] = fmt.Errorf("erro ao publicar evento: %w", err)
+	}
+	return publishErrors
+}
+
+// logFailure registra a falha de publicação de uma mensagem
+func (d *OutboxDispatcherImpl) logFailure(message entities.OutboxMessage, err error) {
+	log.Error().
+		Err(err).
 		Uint("message_id", message.ID).
-		Str("topic", topic).
 		Str("event_type", message.EventType).
-		Msg("mensagem da outbox publicada com sucesso")
+		Msg("erro ao processar mensagem da outbox")
+}
 
-	return nil
+// handleFailure registra a falha de publicação e agenda nova tentativa com backoff exponencial.
+// Após maxAttempts tentativas a mensagem é estacionada como DEAD e deixa de ser reservada.
+func (d *OutboxDispatcherImpl) handleFailure(ctx context.Context, message entities.OutboxMessage, cause error) {
+	attempts := message.Attempts + 1
+
+	if attempts >= d.maxAttempts {
+		updated, err := d.outboxService.MarkMessageAsDead(ctx, message.ID, d.instanceID, cause.Error())
+		if err != nil {
+			log.Error().
+				Err(err).
+				Uint("message_id", message.ID).
+				Msg("erro ao marcar mensagem como DEAD")
+			return
+		}
+		if updated == 0 {
+			d.logLeaseLost(message)
+			return
+		}
+		pkgoutboxmetrics.DeadTotal.Inc()
+
+		log.Warn().
+			Uint("message_id", message.ID).
+			Str("event_type", message.EventType).
+			Int("attempts", attempts).
+			Msg("mensagem da outbox excedeu o máximo de tentativas e foi marcada como DEAD")
+		return
+	}
+
+	nextAttemptAt := time.Now().Add(d.backoff(attempts))
+	updated, err := d.outboxService.MarkMessageAsFailed(ctx, message.ID, d.instanceID, cause.Error(), nextAttemptAt)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Uint("message_id", message.ID).
+			Msg("erro ao registrar falha da mensagem")
+		return
+	}
+	if updated == 0 {
+		d.logLeaseLost(message)
+		return
+	}
+
+	log.Info().
+		Uint("message_id", message.ID).
+		Int("attempts", attempts).
+		Time("next_attempt_at", nextAttemptAt).
+		Msg("nova tentativa da mensagem agendada")
 }
 
-// getTopicForEvent mapeia tipos de evento para tópicos
-func (d *OutboxDispatcherImpl) getTopicForEvent(eventType string) string {
-	// Mapeamento direto: user.created -> user.created
-	return eventType
+// logLeaseLost registra que a falha não foi gravada porque o lease da mensagem expirou e ela foi
+// reservada por outro dispatcher, que passa a ser responsável pela próxima tentativa
+func (d *OutboxDispatcherImpl) logLeaseLost(message entities.OutboxMessage) {
+	log.Warn().
+		Uint("message_id", message.ID).
+		Str("instance_id", d.instanceID).
+		Msg("lease expirado: mensagem já reservada por outro dispatcher, falha não registrada")
+}
+
+// backoff calcula o atraso da próxima tentativa: base, 2*base, 4*base... limitado a maxBackoff
+func (d *OutboxDispatcherImpl) backoff(attempts int) time.Duration {
+	delay := d.baseBackoff
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= d.maxBackoff {
+			return d.maxBackoff
+		}
+	}
+	return delay
+}
+
+// buildMessage converte a mensagem da outbox na mensagem Kafka a ser publicada
+func (d *OutboxDispatcherImpl) buildMessage(message entities.OutboxMessage) (pkgkafka.Message, error) {
+	// Headers gravados na outbox seguem inalterados como headers Kafka
+	headers, err := message.GetHeaders()
+	if err != nil {
+		return pkgkafka.Message{}, fmt.Errorf("erro ao deserializar headers: %w", err)
+	}
+
+	// O payload já está serializado em JSON e é publicado sem alterações
+	return pkgkafka.Message{
+		Topic:   d.topicRouter.TopicFor(message),
+		Key:     messageKey(message, headers),
+		Value:   []byte(message.Payload),
+		Headers: headers,
+	}, nil
+}
+
+// messageKey deriva a chave Kafka de aggregate + aggregate_id (ex: "order:42"), garantindo
+// que eventos da mesma entidade caiam na mesma partição e sejam consumidos em ordem.
+// Sem aggregate_id (coluna ou header) retorna nil e o producer usa sua chave padrão.
+func messageKey(message entities.OutboxMessage, headers map[string]string) []byte {
+	aggregateID := message.AggregateID
+	if aggregateID == "" {
+		aggregateID = headers[pkgevents.HeaderAggregateID]
+	}
+	if aggregateID == "" {
+		return nil
+	}
+	return []byte(message.Aggregate + ":" + aggregateID)
 }
 
 // SetBatchSize define o tamanho do lote de processamento
@@ -140,6 +357,37 @@ func (d *OutboxDispatcherImpl) SetBatchSize(batchSize int) {
 	d.batchSize = batchSize
 }
 
+// SetMaxInterval define o intervalo máximo de polling quando a outbox está ociosa
+func (d *OutboxDispatcherImpl) SetMaxInterval(maxInterval time.Duration) {
+	if maxInterval < d.interval {
+		maxInterval = d.interval
+	}
+	d.maxInterval = maxInterval
+}
+
+// SetLeaseDuration define por quanto tempo um lote fica reservado para este dispatcher
+func (d *OutboxDispatcherImpl) SetLeaseDuration(lease time.Duration) {
+	d.leaseDuration = lease
+}
+
+// SetRetryPolicy define o número máximo de tentativas e os limites do backoff exponencial
+func (d *OutboxDispatcherImpl) SetRetryPolicy(maxAttempts int, baseBackoff, maxBackoff time.Duration) {
+	d.maxAttempts = maxAttempts
+	d.baseBackoff = baseBackoff
+	d.maxBackoff = maxBackoff
+}
+
+// SetTopicRouter define o roteador de tópicos do dispatcher
+func (d *OutboxDispatcherImpl) SetTopicRouter(router TopicRouter) {
+	d.topicRouter = router
+}
+
+// SetStrictOrdering ativa a garantia de ordem por agregado: se uma mensagem falhar, as seguintes
+// do mesmo agregado só são publicadas depois que ela for publicada com sucesso
+func (d *OutboxDispatcherImpl) SetStrictOrdering(enabled bool) {
+	d.strictOrder = enabled
+}
+
 // GetStats retorna estatísticas do dispatcher
 func (d *OutboxDispatcherImpl) GetStats(ctx context.Context) (map[string]interface{}, error) {
 	pendingCount, err := d.outboxService.GetPendingCount(ctx)
@@ -147,9 +395,26 @@ func (d *OutboxDispatcherImpl) GetStats(ctx context.Context) (map[string]interfa
 		return nil, err
 	}
 
+	statusCounts, err := d.outboxService.GetStatusCounts(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	oldestPendingAge, err := d.outboxService.GetOldestPendingAge(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	return map[string]interface{}{
-		"batch_size":    d.batchSize,
-		"interval":      d.interval.String(),
-		"pending_count": pendingCount,
+		"instance_id":    d.instanceID,
+		"batch_size":     d.batchSize,
+		"interval":       d.interval.String(),
+		"max_interval":   d.maxInterval.String(),
+		"lease_duration": d.leaseDuration.String(),
+		"max_attempts":   d.maxAttempts,
+		"strict_order":   d.strictOrder,
+		"pending_count":  pendingCount,
+		"oldest_pending": oldestPendingAge.String(),
+		"status_counts":  statusCounts,
 	}, nil
 }
@@ -0,0 +1,453 @@
+package dispatcher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	pkgkafka "pkg/kafka"
+	"pkg/outbox/entities"
+	"pkg/outbox/repository"
+	pkgoutboxservices "pkg/outbox/services"
+
+	"github.com/rs/zerolog"
+)
+
+func TestMain(m *testing.M) {
+	zerolog.SetGlobalLevel(zerolog.Disabled)
+	os.Exit(m.Run())
+}
+
+// fakeOutboxRepository outbox em memória com a mesma semântica de lease do repositório GORM.
+// roundTrip simula a latência de cada chamada ao banco.
+type fakeOutboxRepository struct {
+	mu        sync.Mutex
+	messages  map[uint]*entities.OutboxMessage
+	nextID    uint
+	roundTrip time.Duration
+	calls     map[string]int
+}
+
+func newFakeOutboxRepository() *fakeOutboxRepository {
+	return &fakeOutboxRepository{
+		messages: make(map[uint]*entities.OutboxMessage),
+		calls:    make(map[string]int),
+	}
+}
+
+// call registra a chamada e simula a ida ao banco
+func (r *fakeOutboxRepository) call(name string) {
+	r.calls[name]++
+	if r.roundTrip > 0 {
+		time.Sleep(r.roundTrip)
+	}
+}
+
+// add grava mensagens pendentes para os tipos de evento informados
+func (r *fakeOutboxRepository) add(eventTypes ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, eventType := range eventTypes {
+		r.nextID++
+		r.messages[r.nextID] = &entities.OutboxMessage{
+			ID:          r.nextID,
+			Aggregate:   "order",
+			AggregateID: fmt.Sprint(r.nextID),
+			EventType:   eventType,
+			Payload:     `{"id":1}`,
+			Status:      entities.StatusPending,
+			CreatedAt:   time.Now(),
+		}
+	}
+}
+
+// reset remove todas as mensagens
+func (r *fakeOutboxRepository) reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.messages = make(map[uint]*entities.OutboxMessage)
+}
+
+// status retorna o status de cada mensagem
+func (r *fakeOutboxRepository) status() map[uint]string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	statuses := make(map[uint]string, len(r.messages))
+	for id, message := range r.messages {
+		statuses[id] = message.Status
+	}
+	return statuses
+}
+
+func (r *fakeOutboxRepository) Save(ctx context.Context, message *entities.OutboxMessage) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.call("Save")
+	r.nextID++
+	message.ID = r.nextID
+	r.messages[message.ID] = message
+	return nil
+}
+
+func (r *fakeOutboxRepository) GetPending(ctx context.Context, limit int) ([]entities.OutboxMessage, error) {
+	return nil, nil
+}
+
+func (r *fakeOutboxRepository) ClaimPending(ctx context.Context, claimerID string, limit int, lease time.Duration) ([]entities.OutboxMessage, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.call("ClaimPending")
+	now := time.Now()
+	ids := make([]uint, 0, len(r.messages))
+	for id, message := range r.messages {
+		available := message.Status == entities.StatusPending || message.Status == entities.StatusFailed
+		if available && (message.ClaimedUntil == nil || message.ClaimedUntil.Before(now)) {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	if len(ids) > limit {
+		ids = ids[:limit]
+	}
+
+	claimedUntil := now.Add(lease)
+	claimed := make([]entities.OutboxMessage, len(ids))
+	for i, id := range ids {
+		message := r.messages[id]
+		message.ClaimedBy = &claimerID
+		message.ClaimedUntil = &claimedUntil
+		claimed[i] = *message
+	}
+	return claimed, nil
+}
+
+func (r *fakeOutboxRepository) ClaimPendingOrdered(ctx context.Context, claimerID string, limit int, lease time.Duration) ([]entities.OutboxMessage, error) {
+	return r.ClaimPending(ctx, claimerID, limit, lease)
+}
+
+func (r *fakeOutboxRepository) ReleaseClaim(ctx context.Context, id uint, claimerID string) error {
+	return nil
+}
+
+// claimedLocked retorna a mensagem se ainda estiver reservada por claimerID
+func (r *fakeOutboxRepository) claimedLocked(id uint, claimerID string) (*entities.OutboxMessage, bool) {
+	message, ok := r.messages[id]
+	if !ok || message.ClaimedBy == nil || *message.ClaimedBy != claimerID {
+		return nil, false
+	}
+	return message, true
+}
+
+func (r *fakeOutboxRepository) MarkAsProcessed(ctx context.Context, ids []uint, claimerID string) (int64, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.call("MarkAsProcessed")
+	now := time.Now()
+	var updated int64
+	for _, id := range ids {
+		message, ok := r.claimedLocked(id, claimerID)
+		if !ok {
+			continue
+		}
+		message.Status = entities.StatusProcessed
+		message.ProcessedAt = &now
+		message.ClaimedBy = nil
+		message.ClaimedUntil = nil
+		updated++
+	}
+	return updated, nil
+}
+
+func (r *fakeOutboxRepository) MarkAsFailed(ctx context.Context, id uint, claimerID, lastError string, nextAttemptAt time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.call("MarkAsFailed")
+	message, ok := r.claimedLocked(id, claimerID)
+	if !ok {
+		return 0, nil
+	}
+	message.Status = entities.StatusFailed
+	message.Attempts++
+	message.LastError = &lastError
+	message.NextAttemptAt = &nextAttemptAt
+	message.ClaimedBy = nil
+	message.ClaimedUntil = nil
+	return 1, nil
+}
+
+func (r *fakeOutboxRepository) MarkAsDead(ctx context.Context, id uint, claimerID, lastError string) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.call("MarkAsDead")
+	message, ok := r.claimedLocked(id, claimerID)
+	if !ok {
+		return 0, nil
+	}
+	message.Status = entities.StatusDead
+	message.Attempts++
+	message.LastError = &lastError
+	message.ClaimedBy = nil
+	message.ClaimedUntil = nil
+	return 1, nil
+}
+
+func (r *fakeOutboxRepository) GetByStatus(ctx context.Context, status string, limit int) ([]entities.OutboxMessage, error) {
+	return nil, nil
+}
+
+func (r *fakeOutboxRepository) CountByStatus(ctx context.Context) (map[string]int64, error) {
+	return nil, nil
+}
+
+func (r *fakeOutboxRepository) CountPending(ctx context.Context) (int64, error) {
+	return 0, nil
+}
+
+func (r *fakeOutboxRepository) GetOldestPendingCreatedAt(ctx context.Context) (*time.Time, error) {
+	return nil, nil
+}
+
+func (r *fakeOutboxRepository) List(ctx context.Context, filter repository.OutboxFilter) ([]entities.OutboxMessage, error) {
+	return nil, nil
+}
+
+func (r *fakeOutboxRepository) Requeue(ctx context.Context, id uint) (bool, error) {
+	return false, nil
+}
+
+func (r *fakeOutboxRepository) RequeueByStatus(ctx context.Context, status string) (int64, error) {
+	return 0, nil
+}
+
+func (r *fakeOutboxRepository) GetProcessedBefore(ctx context.Context, before time.Time, limit int) ([]entities.OutboxMessage, error) {
+	return nil, nil
+}
+
+func (r *fakeOutboxRepository) DeleteProcessed(ctx context.Context, ids []uint) (int64, error) {
+	return 0, nil
+}
+
+func (r *fakeOutboxRepository) GetByID(ctx context.Context, id uint) (*entities.OutboxMessage, error) {
+	return nil, errors.New("não implementado")
+}
+
+// recordingProducer guarda as mensagens publicadas por tópico, sem Kafka
+type recordingProducer struct {
+	mu        sync.Mutex
+	published map[string][]pkgkafka.Message
+}
+
+func newRecordingProducer() *recordingProducer {
+	return &recordingProducer{published: make(map[string][]pkgkafka.Message)}
+}
+
+func (p *recordingProducer) Publish(ctx context.Context, topic string, key, value []byte, headers map[string]string) error {
+	return p.PublishBatch(ctx, []pkgkafka.Message{{Topic: topic, Key: key, Value: value, Headers: headers}})
+}
+
+func (p *recordingProducer) PublishBatch(ctx context.Context, messages []pkgkafka.Message) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, message := range messages {
+		p.published[message.Topic] = append(p.published[message.Topic], message)
+	}
+	return nil
+}
+
+// messages retorna as mensagens publicadas no tópico
+func (p *recordingProducer) messages(topic string) []pkgkafka.Message {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.published[topic]
+}
+
+// partialFailureProducer publica as mensagens aceitas, mas rejeita as dos tópicos informados com
+// um BatchError, como o Producer Kafka em uma falha parcial
+type partialFailureProducer struct {
+	*recordingProducer
+	failTopics map[string]bool
+}
+
+func (p *partialFailureProducer) PublishBatch(ctx context.Context, messages []pkgkafka.Message) error {
+	batchErr := make(pkgkafka.BatchError, len(messages))
+	var accepted []pkgkafka.Message
+	failed := false
+	for i, message := range messages {
+		if p.failTopics[message.Topic] {
+			batchErr[i] = errors.New("partição indisponível")
+			failed = true
+			continue
+		}
+		accepted = append(accepted, message)
+	}
+
+	if err := p.recordingProducer.PublishBatch(ctx, accepted); err != nil {
+		return err
+	}
+	if failed {
+		return batchErr
+	}
+	return nil
+}
+
+func newTestDispatcher(repo *fakeOutboxRepository, producer Producer) *OutboxDispatcherImpl {
+	service := pkgoutboxservices.NewOutboxService(repo)
+	return NewOutboxDispatcher(service, producer, time.Second).(*OutboxDispatcherImpl)
+}
+
+func TestProcessPendingPartialBatchErrorMarksOnlyFailedRows(t *testing.T) {
+	repo := newFakeOutboxRepository()
+	repo.add("order.created", "order.paid", "order.created", "order.paid")
+
+	producer := &partialFailureProducer{
+		recordingProducer: newRecordingProducer(),
+		failTopics:        map[string]bool{"order.paid": true},
+	}
+	d := newTestDispatcher(repo, producer)
+
+	count, err := d.processPending(context.Background())
+	if err != nil {
+		t.Fatalf("processPending: %v", err)
+	}
+	if count != 4 {
+		t.Fatalf("processPending reservou %d mensagens, esperado 4", count)
+	}
+
+	want := map[uint]string{
+		1: entities.StatusProcessed,
+		2: entities.StatusFailed,
+		3: entities.StatusProcessed,
+		4: entities.StatusFailed,
+	}
+	for id, status := range repo.status() {
+		if status != want[id] {
+			t.Errorf("mensagem %d com status %s, esperado %s", id, status, want[id])
+		}
+	}
+
+	if got := repo.calls["MarkAsProcessed"]; got != 1 {
+		t.Errorf("MarkAsProcessed chamado %d vezes, esperado 1 (UPDATE em lote)", got)
+	}
+	if got := repo.calls["MarkAsFailed"]; got != 2 {
+		t.Errorf("MarkAsFailed chamado %d vezes, esperado 2", got)
+	}
+	if got := len(producer.messages("order.created")); got != 2 {
+		t.Errorf("%d mensagens publicadas em order.created, esperado 2", got)
+	}
+	if got := len(producer.messages("order.paid")); got != 0 {
+		t.Errorf("%d mensagens publicadas em order.paid, esperado 0", got)
+	}
+}
+
+func TestProcessPendingSkipsRowsClaimedByAnotherDispatcher(t *testing.T) {
+	repo := newFakeOutboxRepository()
+	repo.add("order.created")
+
+	d := newTestDispatcher(repo, newRecordingProducer())
+	messages, err := repo.ClaimPending(context.Background(), "other-dispatcher", 10, time.Minute)
+	if err != nil || len(messages) != 1 {
+		t.Fatalf("ClaimPending: %v (%d mensagens)", err, len(messages))
+	}
+
+	// A falha de uma mensagem reservada por outro dispatcher não é gravada
+	d.handleFailure(context.Background(), messages[0], errors.New("falha"))
+	if status := repo.status()[1]; status != entities.StatusPending {
+		t.Errorf("mensagem com status %s, esperado %s", status, entities.StatusPending)
+	}
+}
+
+// BenchmarkDispatch compara a publicação mensagem a mensagem (Publish + MarkAsProcessed por
+// mensagem) com a publicação em lote (PublishBatch + um MarkAsProcessed para o lote), com uma
+// latência simulada por chamada ao banco
+func BenchmarkDispatch(b *testing.B) {
+	const batchSize = 100
+	eventTypes := make([]string, batchSize)
+	for i := range eventTypes {
+		eventTypes[i] = "order.created"
+	}
+
+	setup := func() (*fakeOutboxRepository, *OutboxDispatcherImpl) {
+		repo := newFakeOutboxRepository()
+		repo.roundTrip = 100 * time.Microsecond
+		d := newTestDispatcher(repo, newRecordingProducer())
+		d.SetBatchSize(batchSize)
+		return repo, d
+	}
+
+	b.Run("per_message", func(b *testing.B) {
+		repo, d := setup()
+		ctx := context.Background()
+		for i := 0; i < b.N; i++ {
+			b.StopTimer()
+			repo.reset()
+			repo.add(eventTypes...)
+			b.StartTimer()
+
+			messages, err := d.outboxService.ClaimPendingMessages(ctx, d.instanceID, batchSize, d.leaseDuration)
+			if err != nil {
+				b.Fatal(err)
+			}
+			for _, message := range messages {
+				kafkaMessage, err := d.buildMessage(message)
+				if err != nil {
+					b.Fatal(err)
+				}
+				if err := d.producer.Publish(ctx, kafkaMessage.Topic, kafkaMessage.Key, kafkaMessage.Value, kafkaMessage.Headers); err != nil {
+					b.Fatal(err)
+				}
+				if _, err := d.outboxService.MarkMessageAsProcessed(ctx, message.ID, d.instanceID); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+		b.ReportMetric(float64(b.N*batchSize)/b.Elapsed().Seconds(), "msgs/s")
+	})
+
+	b.Run("batch", func(b *testing.B) {
+		repo, d := setup()
+		ctx := context.Background()
+		for i := 0; i < b.N; i++ {
+			b.StopTimer()
+			repo.reset()
+			repo.add(eventTypes...)
+			b.StartTimer()
+
+			if _, err := d.processPending(ctx); err != nil {
+				b.Fatal(err)
+			}
+		}
+		b.ReportMetric(float64(b.N*batchSize)/b.Elapsed().Seconds(), "msgs/s")
+	})
+}
+
+func TestRuleTopicRouterTopics(t *testing.T) {
+	router := NewRuleTopicRouter("staging.", []TopicRule{
+		{Pattern: "order.*", Topic: "orders"},
+		{Pattern: "payment.*", Topic: "payments"},
+	})
+
+	got := router.Topics([]string{"order.created", "order.paid", "user.created"})
+	want := []string{"staging.orders", "staging.user.created", "staging.payments"}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("Topics() = %v, esperado %v", got, want)
+	}
+}
@@ -2,16 +2,24 @@ package dispatcher
 
 import (
 	"context"
+	"time"
+	pkgkafka "pkg/kafka"
 )
 
 // Producer interface para publicação no Kafka
 type Producer interface {
-	PublishEvent(ctx context.Context, topic string, event interface{}) error
+	Publish(ctx context.Context, topic string, key, value []byte, headers map[string]string) error
+	PublishBatch(ctx context.Context, messages []pkgkafka.Message) error
 }
 
 // OutboxDispatcher interface para processamento de mensagens da outbox
 type OutboxDispatcher interface {
 	Start(ctx context.Context)
 	SetBatchSize(batchSize int)
+	SetMaxInterval(maxInterval time.Duration)
+	SetLeaseDuration(lease time.Duration)
+	SetRetryPolicy(maxAttempts int, baseBackoff, maxBackoff time.Duration)
+	SetTopicRouter(router TopicRouter)
+	SetStrictOrdering(enabled bool)
 	GetStats(ctx context.Context) (map[string]interface{}, error)
 }
@@ -0,0 +1,47 @@
+package dispatcher
+
+import (
+	"pkg/outbox/entities"
+	pkgkafka "pkg/kafka"
+)
+
+// TopicRouter define o tópico Kafka de destino de uma mensagem da outbox
+type TopicRouter interface {
+	TopicFor(message entities.OutboxMessage) string
+}
+
+// TopicRule regra de roteamento por event_type (ver pkgkafka.TopicRule)
+type TopicRule = pkgkafka.TopicRule
+
+// RuleTopicRouter roteia pelo event_type da mensagem com as regras de pkgkafka.TopicRules, que
+// também são usadas no provisionamento dos tópicos
+type RuleTopicRouter struct {
+	rules pkgkafka.TopicRules
+}
+
+// NewTopicRouter cria um roteador de tópicos com prefixo e regras opcionais
+func NewTopicRouter(prefix string, rules []TopicRule) TopicRouter {
+	return NewRuleTopicRouter(prefix, rules)
+}
+
+// NewRuleTopicRouter cria o roteador por regras, com acesso à lista de tópicos de destino
+func NewRuleTopicRouter(prefix string, rules []TopicRule) *RuleTopicRouter {
+	return &RuleTopicRouter{
+		rules: pkgkafka.TopicRules{Prefix: prefix, Rules: rules},
+	}
+}
+
+// TopicFor retorna o tópico da mensagem
+func (r *RuleTopicRouter) TopicFor(message entities.OutboxMessage) string {
+	return r.rules.TopicFor(message.EventType)
+}
+
+// Topics retorna os tópicos em que o roteador pode publicar, já com o prefixo
+func (r *RuleTopicRouter) Topics(eventTypes []string) []string {
+	return r.rules.Topics(eventTypes)
+}
+
+// ParseTopicRules interpreta regras no formato "order.*=orders,user.created=users"
+func ParseTopicRules(spec string) ([]TopicRule, error) {
+	return pkgkafka.ParseTopicRules(spec)
+}
@@ -2,18 +2,38 @@ package entities
 
 import (
 	"database/sql"
+	"encoding/json"
 	"time"
 )
 
+// Status possíveis de uma mensagem da outbox
+const (
+	StatusPending   = "PENDING"   // Aguardando publicação
+	StatusFailed    = "FAILED"    // Falhou, aguardando nova tentativa em NextAttemptAt
+	StatusDead      = "DEAD"      // Excedeu o número máximo de tentativas; requer intervenção
+	StatusProcessed = "PROCESSED" // Publicada com sucesso
+)
+
 // OutboxMessage representa uma mensagem na tabela outbox
 type OutboxMessage struct {
 	ID          uint           `gorm:"primaryKey"`
 	Aggregate   string         `gorm:"not null"`
+	AggregateID string         `gorm:"size:100;not null;default:''"` // Chave de ordenação por entidade (vazio = sem ordem)
 	EventType   string         `gorm:"not null"`
 	Payload     string         `gorm:"type:json;not null"`
 	Headers     sql.NullString `gorm:"type:json"`
 	CreatedAt   time.Time      `gorm:"not null"`
 	ProcessedAt *time.Time     `gorm:"null"`
+
+	// Controle de tentativas de publicação
+	Status        string     `gorm:"size:20;not null;default:PENDING"`
+	Attempts      int        `gorm:"not null;default:0"`
+	LastError     *string    `gorm:"type:text"`
+	NextAttemptAt *time.Time `gorm:"null"`
+
+	// Lease de processamento: evita que dispatchers concorrentes publiquem a mesma mensagem
+	ClaimedBy    *string    `gorm:"size:100"`
+	ClaimedUntil *time.Time `gorm:"null"`
 }
 
 // TableName especifica o nome da tabela
@@ -21,13 +41,52 @@ func (OutboxMessage) TableName() string {
 	return "outbox"
 }
 
+// SetHeaders serializa os headers na coluna JSON (nil ou vazio grava NULL)
+func (m *OutboxMessage) SetHeaders(headers map[string]string) error {
+	if len(headers) == 0 {
+		m.Headers = sql.NullString{}
+		return nil
+	}
+
+	headersBytes, err := json.Marshal(headers)
+	if err != nil {
+		return err
+	}
+	m.Headers = sql.NullString{String: string(headersBytes), Valid: true}
+	return nil
+}
+
+// GetHeaders deserializa os headers da coluna JSON
+func (m *OutboxMessage) GetHeaders() (map[string]string, error) {
+	if !m.Headers.Valid || m.Headers.String == "" {
+		return nil, nil
+	}
+
+	var headers map[string]string
+	if err := json.Unmarshal([]byte(m.Headers.String), &headers); err != nil {
+		return nil, err
+	}
+	return headers, nil
+}
+
 // IsProcessed verifica se a mensagem já foi processada
 func (m *OutboxMessage) IsProcessed() bool {
 	return m.ProcessedAt != nil
 }
 
+// IsDead verifica se a mensagem excedeu o número máximo de tentativas
+func (m *OutboxMessage) IsDead() bool {
+	return m.Status == StatusDead
+}
+
+// IsClaimed verifica se a mensagem está reservada por algum dispatcher
+func (m *OutboxMessage) IsClaimed() bool {
+	return m.ClaimedUntil != nil && m.ClaimedUntil.After(time.Now())
+}
+
 // MarkAsProcessed marca a mensagem como processada
 func (m *OutboxMessage) MarkAsProcessed() {
 	now := time.Now()
 	m.ProcessedAt = &now
+	m.Status = StatusProcessed
 }
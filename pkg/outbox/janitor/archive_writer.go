@@ -0,0 +1,96 @@
+package janitor
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+	"pkg/outbox/entities"
+)
+
+// archiveRecord formato de cada linha do arquivo NDJSON de arquivamento
+type archiveRecord struct {
+	ID          uint            `json:"id"`
+	Aggregate   string          `json:"aggregate"`
+	AggregateID string          `json:"aggregate_id,omitempty"`
+	EventType   string          `json:"event_type"`
+	Payload     json.RawMessage `json:"payload"`
+	Headers     json.RawMessage `json:"headers,omitempty"`
+	Status      string          `json:"status"`
+	Attempts    int             `json:"attempts"`
+	CreatedAt   time.Time       `json:"created_at"`
+	ProcessedAt *time.Time      `json:"processed_at"`
+}
+
+// archiveWriter grava mensagens em um arquivo NDJSON comprimido com gzip
+type archiveWriter struct {
+	file    *os.File
+	gz      *gzip.Writer
+	encoder *json.Encoder
+}
+
+// newArchiveWriter cria o arquivo outbox-<timestamp>.ndjson.gz no diretório informado
+func newArchiveWriter(dir string, now time.Time) (*archiveWriter, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("erro ao criar diretório de arquivamento: %w", err)
+	}
+
+	path := filepath.Join(dir, fmt.Sprintf("outbox-%s.ndjson.gz", now.UTC().Format("20060102T150405Z")))
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao abrir arquivo de arquivamento: %w", err)
+	}
+
+	gz := gzip.NewWriter(file)
+	return &archiveWriter{
+		file:    file,
+		gz:      gz,
+		encoder: json.NewEncoder(gz),
+	}, nil
+}
+
+// Write grava um lote e força a escrita em disco, para que o lote só seja removido
+// do banco depois de estar persistido no arquivo
+func (w *archiveWriter) Write(messages []entities.OutboxMessage) error {
+	for i := range messages {
+		record := archiveRecord{
+			ID:          messages[i].ID,
+			Aggregate:   messages[i].Aggregate,
+			AggregateID: messages[i].AggregateID,
+			EventType:   messages[i].EventType,
+			Payload:     json.RawMessage(messages[i].Payload),
+			Status:      messages[i].Status,
+			Attempts:    messages[i].Attempts,
+			CreatedAt:   messages[i].CreatedAt,
+			ProcessedAt: messages[i].ProcessedAt,
+		}
+		if messages[i].Headers.Valid {
+			record.Headers = json.RawMessage(messages[i].Headers.String)
+		}
+
+		if err := w.encoder.Encode(record); err != nil {
+			return fmt.Errorf("erro ao gravar mensagem %d no arquivo: %w", messages[i].ID, err)
+		}
+	}
+
+	if err := w.gz.Flush(); err != nil {
+		return fmt.Errorf("erro ao descarregar arquivo de arquivamento: %w", err)
+	}
+	return w.file.Sync()
+}
+
+// Name retorna o caminho do arquivo
+func (w *archiveWriter) Name() string {
+	return w.file.Name()
+}
+
+// Close finaliza o stream gzip e fecha o arquivo
+func (w *archiveWriter) Close() error {
+	if err := w.gz.Close(); err != nil {
+		w.file.Close()
+		return err
+	}
+	return w.file.Close()
+}
@@ -0,0 +1,134 @@
+package janitor
+
+import (
+	"context"
+	"fmt"
+	"time"
+	pkgoutboxservices "pkg/outbox/services"
+
+	"github.com/rs/zerolog/log"
+)
+
+// batchPause pausa entre lotes para não monopolizar a tabela outbox
+const batchPause = 100 * time.Millisecond
+
+// OutboxJanitorImpl implementação da limpeza periódica da outbox
+type OutboxJanitorImpl struct {
+	outboxService pkgoutboxservices.OutboxService
+	config        Config
+}
+
+// NewOutboxJanitor cria um novo janitor da outbox
+func NewOutboxJanitor(outboxService pkgoutboxservices.OutboxService, config Config) OutboxJanitor {
+	if config.BatchSize <= 0 {
+		config.BatchSize = 500
+	}
+	if config.Interval <= 0 {
+		config.Interval = time.Hour
+	}
+
+	return &OutboxJanitorImpl{
+		outboxService: outboxService,
+		config:        config,
+	}
+}
+
+// Start executa a limpeza periodicamente até o contexto ser cancelado
+func (j *OutboxJanitorImpl) Start(ctx context.Context) {
+	log.Info().
+		Dur("retention", j.config.Retention).
+		Dur("interval", j.config.Interval).
+		Int("batch_size", j.config.BatchSize).
+		Str("archive_dir", j.config.ArchiveDir).
+		Msg("iniciando outbox janitor")
+
+	ticker := time.NewTicker(j.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Info().Msg("outbox janitor interrompido")
+			return
+		case <-ticker.C:
+			if _, err := j.RunOnce(ctx); err != nil {
+				log.Error().Err(err).Msg("erro ao limpar mensagens processadas da outbox")
+			}
+		}
+	}
+}
+
+// RunOnce remove, em lotes, as mensagens processadas há mais tempo que a retenção.
+// Com ArchiveDir configurado, cada lote é gravado no arquivo antes de ser removido.
+func (j *OutboxJanitorImpl) RunOnce(ctx context.Context) (int64, error) {
+	now := time.Now()
+	cutoff := now.Add(-j.config.Retention)
+
+	var archive *archiveWriter
+	defer func() {
+		if archive == nil {
+			return
+		}
+		if err := archive.Close(); err != nil {
+			log.Error().Err(err).Str("file", archive.Name()).Msg("erro ao fechar arquivo de arquivamento")
+		}
+	}()
+
+	var total int64
+	for {
+		messages, err := j.outboxService.GetProcessedMessagesBefore(ctx, cutoff, j.config.BatchSize)
+		if err != nil {
+			return total, fmt.Errorf("erro ao buscar mensagens processadas: %w", err)
+		}
+
+		if len(messages) == 0 {
+			break
+		}
+
+		if j.config.ArchiveDir != "" {
+			// O arquivo só é criado quando há algo para arquivar
+			if archive == nil {
+				archive, err = newArchiveWriter(j.config.ArchiveDir, now)
+				if err != nil {
+					return total, err
+				}
+			}
+			if err := archive.Write(messages); err != nil {
+				return total, err
+			}
+		}
+
+		ids := make([]uint, len(messages))
+		for i := range messages {
+			ids[i] = messages[i].ID
+		}
+
+		deleted, err := j.outboxService.DeleteProcessedMessages(ctx, ids)
+		if err != nil {
+			return total, fmt.Errorf("erro ao remover mensagens processadas: %w", err)
+		}
+		total += deleted
+
+		if len(messages) < j.config.BatchSize {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			return total, ctx.Err()
+		case <-time.After(batchPause):
+		}
+	}
+
+	if total > 0 {
+		event := log.Info().
+			Int64("deleted", total).
+			Time("cutoff", cutoff)
+		if archive != nil {
+			event = event.Str("archive_file", archive.Name())
+		}
+		event.Msg("mensagens processadas removidas da outbox")
+	}
+
+	return total, nil
+}
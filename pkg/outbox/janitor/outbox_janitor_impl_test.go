@@ -0,0 +1,261 @@
+package janitor
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"pkg/outbox/entities"
+	pkgoutboxservices "pkg/outbox/services"
+)
+
+// fakeOutboxService guarda as mensagens em memória. Embute a interface para implementar apenas o
+// que o janitor usa.
+type fakeOutboxService struct {
+	pkgoutboxservices.OutboxService
+
+	messages  []entities.OutboxMessage
+	batches   []int // Tamanho de cada lote removido
+	fetchErr  error
+	deleteErr error
+}
+
+func (s *fakeOutboxService) GetProcessedMessagesBefore(ctx context.Context, before time.Time, limit int) ([]entities.OutboxMessage, error) {
+	if s.fetchErr != nil {
+		return nil, s.fetchErr
+	}
+	var result []entities.OutboxMessage
+	for _, message := range s.messages {
+		if message.ProcessedAt != nil && message.ProcessedAt.Before(before) && len(result) < limit {
+			result = append(result, message)
+		}
+	}
+	return result, nil
+}
+
+func (s *fakeOutboxService) DeleteProcessedMessages(ctx context.Context, ids []uint) (int64, error) {
+	if s.deleteErr != nil {
+		return 0, s.deleteErr
+	}
+	remove := make(map[uint]bool, len(ids))
+	for _, id := range ids {
+		remove[id] = true
+	}
+	kept := s.messages[:0]
+	for _, message := range s.messages {
+		if !remove[message.ID] {
+			kept = append(kept, message)
+		}
+	}
+	s.messages = kept
+	s.batches = append(s.batches, len(ids))
+	return int64(len(ids)), nil
+}
+
+// processedMessages cria count mensagens processadas há age
+func processedMessages(firstID uint, count int, age time.Duration) []entities.OutboxMessage {
+	processedAt := time.Now().Add(-age)
+	messages := make([]entities.OutboxMessage, count)
+	for i := range messages {
+		messages[i] = entities.OutboxMessage{
+			ID:          firstID + uint(i),
+			Aggregate:   "order",
+			EventType:   "order.created",
+			Payload:     `{"id":1}`,
+			Status:      entities.StatusProcessed,
+			CreatedAt:   processedAt,
+			ProcessedAt: &processedAt,
+		}
+	}
+	return messages
+}
+
+func TestRunOnce(t *testing.T) {
+	storageErr := errors.New("conexão perdida")
+
+	tests := []struct {
+		name        string
+		messages    []entities.OutboxMessage
+		batchSize   int
+		fetchErr    error
+		deleteErr   error
+		wantDeleted int64
+		wantBatches []int
+		wantKept    int
+		wantErr     error
+	}{
+		{
+			name:        "nada a remover",
+			messages:    processedMessages(1, 3, time.Hour),
+			batchSize:   10,
+			wantDeleted: 0,
+			wantKept:    3,
+		},
+		{
+			name:        "um lote incompleto",
+			messages:    processedMessages(1, 3, 48*time.Hour),
+			batchSize:   10,
+			wantDeleted: 3,
+			wantBatches: []int{3},
+		},
+		{
+			name:        "vários lotes",
+			messages:    processedMessages(1, 5, 48*time.Hour),
+			batchSize:   2,
+			wantDeleted: 5,
+			wantBatches: []int{2, 2, 1},
+		},
+		{
+			name:        "lote exato seguido de busca vazia",
+			messages:    processedMessages(1, 4, 48*time.Hour),
+			batchSize:   2,
+			wantDeleted: 4,
+			wantBatches: []int{2, 2},
+		},
+		{
+			name:        "mantém as mensagens dentro da retenção",
+			messages:    append(processedMessages(1, 2, 48*time.Hour), processedMessages(10, 2, time.Hour)...),
+			batchSize:   10,
+			wantDeleted: 2,
+			wantBatches: []int{2},
+			wantKept:    2,
+		},
+		{
+			name:      "erro ao buscar",
+			messages:  processedMessages(1, 3, 48*time.Hour),
+			batchSize: 10,
+			fetchErr:  storageErr,
+			wantKept:  3,
+			wantErr:   storageErr,
+		},
+		{
+			name:      "erro ao remover",
+			messages:  processedMessages(1, 3, 48*time.Hour),
+			batchSize: 10,
+			deleteErr: storageErr,
+			wantKept:  3,
+			wantErr:   storageErr,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &fakeOutboxService{messages: tt.messages, fetchErr: tt.fetchErr, deleteErr: tt.deleteErr}
+			janitor := NewOutboxJanitor(service, Config{Retention: 24 * time.Hour, BatchSize: tt.batchSize})
+
+			deleted, err := janitor.RunOnce(context.Background())
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("RunOnce() erro = %v, esperado %v", err, tt.wantErr)
+			}
+			if deleted != tt.wantDeleted {
+				t.Errorf("RunOnce() = %d, esperado %d", deleted, tt.wantDeleted)
+			}
+			if len(service.batches) != len(tt.wantBatches) {
+				t.Fatalf("lotes removidos %v, esperado %v", service.batches, tt.wantBatches)
+			}
+			for i := range tt.wantBatches {
+				if service.batches[i] != tt.wantBatches[i] {
+					t.Errorf("lotes removidos %v, esperado %v", service.batches, tt.wantBatches)
+					break
+				}
+			}
+			if len(service.messages) != tt.wantKept {
+				t.Errorf("%d mensagens mantidas, esperado %d", len(service.messages), tt.wantKept)
+			}
+		})
+	}
+}
+
+func TestRunOnceArchivesBeforeDeleting(t *testing.T) {
+	tests := []struct {
+		name      string
+		messages  []entities.OutboxMessage
+		wantFiles int
+		wantIDs   []uint
+	}{
+		{
+			name:      "sem mensagens não cria arquivo",
+			messages:  processedMessages(1, 2, time.Hour),
+			wantFiles: 0,
+		},
+		{
+			name:      "todos os lotes no mesmo arquivo",
+			messages:  processedMessages(1, 3, 48*time.Hour),
+			wantFiles: 1,
+			wantIDs:   []uint{1, 2, 3},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			service := &fakeOutboxService{messages: tt.messages}
+			janitor := NewOutboxJanitor(service, Config{Retention: 24 * time.Hour, BatchSize: 2, ArchiveDir: dir})
+
+			if _, err := janitor.RunOnce(context.Background()); err != nil {
+				t.Fatalf("RunOnce() erro = %v", err)
+			}
+
+			files, err := filepath.Glob(filepath.Join(dir, "outbox-*.ndjson.gz"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(files) != tt.wantFiles {
+				t.Fatalf("%d arquivos, esperado %d", len(files), tt.wantFiles)
+			}
+			if tt.wantFiles == 0 {
+				return
+			}
+
+			records := readArchive(t, files[0])
+			if len(records) != len(tt.wantIDs) {
+				t.Fatalf("%d registros arquivados, esperado %d", len(records), len(tt.wantIDs))
+			}
+			for i, record := range records {
+				if record.ID != tt.wantIDs[i] || record.EventType != "order.created" || string(record.Payload) != `{"id":1}` {
+					t.Errorf("registro %d = %+v", i, record)
+				}
+				if record.ProcessedAt == nil {
+					t.Errorf("registro %d sem processed_at", i)
+				}
+			}
+		})
+	}
+}
+
+// readArchive lê os registros de um arquivo NDJSON gzip
+func readArchive(t *testing.T, path string) []archiveRecord {
+	t.Helper()
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer gz.Close()
+
+	var records []archiveRecord
+	scanner := bufio.NewScanner(gz)
+	for scanner.Scan() {
+		var record archiveRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			t.Fatalf("linha inválida %q: %v", scanner.Text(), err)
+		}
+		records = append(records, record)
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return records
+}
@@ -0,0 +1,20 @@
+package janitor
+
+import (
+	"context"
+	"time"
+)
+
+// Config configuração da limpeza da outbox
+type Config struct {
+	Retention  time.Duration // Idade mínima (desde processed_at) para remover uma mensagem
+	Interval   time.Duration // Intervalo entre execuções
+	BatchSize  int           // Mensagens removidas por lote, para evitar locks longos
+	ArchiveDir string        // Se preenchido, arquiva em NDJSON gzip antes de remover
+}
+
+// OutboxJanitor interface para limpeza de mensagens processadas da outbox
+type OutboxJanitor interface {
+	Start(ctx context.Context)
+	RunOnce(ctx context.Context) (int64, error)
+}
@@ -0,0 +1,100 @@
+package metrics
+
+import (
+	"context"
+	"time"
+	pkgoutboxservices "pkg/outbox/services"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/rs/zerolog/log"
+)
+
+// Métricas do dispatcher. Taxas de publicação e de falha são obtidas com rate() sobre os contadores.
+var (
+	// PublishedTotal mensagens publicadas com sucesso, por tópico
+	PublishedTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "outbox_published_total",
+		Help: "Mensagens da outbox publicadas com sucesso.",
+	}, []string{"topic"})
+
+	// FailuresTotal falhas de publicação, por tópico
+	FailuresTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "outbox_publish_failures_total",
+		Help: "Falhas de publicação de mensagens da outbox.",
+	}, []string{"topic"})
+
+	// DeadTotal mensagens estacionadas como DEAD após exceder o máximo de tentativas
+	DeadTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "outbox_dead_total",
+		Help: "Mensagens da outbox marcadas como DEAD.",
+	})
+
+	// PublishDuration duração de cada chamada de publicação de lote no broker
+	PublishDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name:    "outbox_publish_duration_seconds",
+		Help:    "Duração da publicação de um lote da outbox no broker.",
+		Buckets: prometheus.DefBuckets,
+	})
+
+	// DeliveryLatency tempo entre a gravação na outbox e a publicação da mensagem
+	DeliveryLatency = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name:    "outbox_delivery_latency_seconds",
+		Help:    "Tempo entre a criação da mensagem na outbox e sua publicação.",
+		Buckets: []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 300, 900},
+	})
+)
+
+// collectTimeout limita as consultas feitas a cada scrape
+const collectTimeout = 5 * time.Second
+
+// backlogCollector exporta o tamanho e a idade do backlog consultando o banco a cada scrape
+type backlogCollector struct {
+	outboxService pkgoutboxservices.OutboxService
+	pending       *prometheus.Desc
+	oldestAge     *prometheus.Desc
+}
+
+// RegisterBacklogCollector registra os gauges outbox_pending_messages e
+// outbox_oldest_pending_age_seconds, usados para alertar sobre uma outbox travada
+func RegisterBacklogCollector(outboxService pkgoutboxservices.OutboxService) error {
+	return prometheus.Register(&backlogCollector{
+		outboxService: outboxService,
+		pending: prometheus.NewDesc(
+			"outbox_pending_messages",
+			"Mensagens da outbox aguardando publicação (PENDING e FAILED).",
+			nil, nil,
+		),
+		oldestAge: prometheus.NewDesc(
+			"outbox_oldest_pending_age_seconds",
+			"Idade da mensagem pendente mais antiga da outbox.",
+			nil, nil,
+		),
+	})
+}
+
+// Describe implementa prometheus.Collector
+func (c *backlogCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.pending
+	ch <- c.oldestAge
+}
+
+// Collect implementa prometheus.Collector
+func (c *backlogCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), collectTimeout)
+	defer cancel()
+
+	pending, err := c.outboxService.GetPendingCount(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("erro ao contar mensagens pendentes da outbox")
+	} else {
+		ch <- prometheus.MustNewConstMetric(c.pending, prometheus.GaugeValue, float64(pending))
+	}
+
+	oldestAge, err := c.outboxService.GetOldestPendingAge(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("erro ao consultar mensagem pendente mais antiga da outbox")
+	} else {
+		ch <- prometheus.MustNewConstMetric(c.oldestAge, prometheus.GaugeValue, oldestAge.Seconds())
+	}
+}
@@ -29,6 +29,7 @@ func CreateOutboxMessage(aggregate, eventType string, payload interface{}) (*ent
 		Aggregate: aggregate,
 		EventType: eventType,
 		Payload:   string(payloadBytes),
+		Status:    entities.StatusPending,
 		CreatedAt: time.Now(),
 	}, nil
 }
@@ -2,10 +2,12 @@ package repository
 
 import (
 	"context"
+	"database/sql"
 	"time"
 	"pkg/outbox/entities"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // GormOutboxRepository implementação usando GORM para outbox
@@ -28,19 +30,291 @@ func (r *GormOutboxRepository) GetPending(ctx context.Context, limit int) ([]ent
 	var messages []entities.OutboxMessage
 	err := r.db.WithContext(ctx).
 		Where("processed_at IS NULL").
-		Order("created_at ASC").
+		Order("id ASC").
 		Limit(limit).
 		Find(&messages).Error
 	return messages, err
 }
 
-// MarkAsProcessed marca uma mensagem como processada
-func (r *GormOutboxRepository) MarkAsProcessed(ctx context.Context, id uint) error {
-	now := time.Now()
+// ClaimPending reserva atomicamente um lote de mensagens pendentes para o dispatcher informado.
+// As linhas são travadas com SELECT ... FOR UPDATE SKIP LOCKED, de modo que dispatchers
+// concorrentes recebem lotes disjuntos, e recebem um lease até claimed_until. Se o dispatcher
+// cair antes de processar, as mensagens voltam a ficar disponíveis quando o lease expirar.
+func (r *GormOutboxRepository) ClaimPending(ctx context.Context, claimerID string, limit int, lease time.Duration) ([]entities.OutboxMessage, error) {
+	return r.claim(ctx, claimerID, limit, lease, false)
+}
+
+// ClaimPendingOrdered funciona como ClaimPending, mas só reserva a mensagem mais antiga ainda não
+// processada de cada agregado (aggregate + aggregate_id). Enquanto ela estiver em retry, DEAD ou
+// reservada por outro dispatcher, as mensagens seguintes do mesmo agregado ficam retidas.
+// Mensagens sem aggregate_id não têm garantia de ordem.
+func (r *GormOutboxRepository) ClaimPendingOrdered(ctx context.Context, claimerID string, limit int, lease time.Duration) ([]entities.OutboxMessage, error) {
+	return r.claim(ctx, claimerID, limit, lease, true)
+}
+
+// claim implementa ClaimPending e ClaimPendingOrdered
+func (r *GormOutboxRepository) claim(ctx context.Context, claimerID string, limit int, lease time.Duration, ordered bool) ([]entities.OutboxMessage, error) {
+	var messages []entities.OutboxMessage
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		query := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("processed_at IS NULL").
+			Where("status IN ?", []string{entities.StatusPending, entities.StatusFailed}).
+			Where("(next_attempt_at IS NULL OR next_attempt_at <= ?)", now).
+			Where("(claimed_until IS NULL OR claimed_until < ?)", now)
+		if ordered {
+			// Cabeça da fila do agregado: não há mensagem anterior (menor id) ainda não processada
+			query = query.Where(`(outbox.aggregate_id = '' OR NOT EXISTS (
+				SELECT 1 FROM outbox AS previous
+				WHERE previous.aggregate = outbox.aggregate
+				AND previous.aggregate_id = outbox.aggregate_id
+				AND previous.id < outbox.id
+				AND previous.processed_at IS NULL))`)
+		}
+
+		if err := query.
+			Order("id ASC").
+			Limit(limit).
+			Find(&messages).Error; err != nil {
+			return err
+		}
+
+		if len(messages) == 0 {
+			return nil
+		}
+
+		ids := make([]uint, len(messages))
+		for i := range messages {
+			ids[i] = messages[i].ID
+		}
+
+		claimedUntil := now.Add(lease)
+		if err := tx.Model(&entities.OutboxMessage{}).
+			Where("id IN ?", ids).
+			Updates(map[string]interface{}{
+				"claimed_by":    claimerID,
+				"claimed_until": claimedUntil,
+			}).Error; err != nil {
+			return err
+		}
+
+		for i := range messages {
+			messages[i].ClaimedBy = &claimerID
+			messages[i].ClaimedUntil = &claimedUntil
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return messages, nil
+}
+
+// ReleaseClaim libera o lease de uma mensagem para que possa ser reprocessada imediatamente
+func (r *GormOutboxRepository) ReleaseClaim(ctx context.Context, id uint, claimerID string) error {
 	return r.db.WithContext(ctx).
 		Model(&entities.OutboxMessage{}).
-		Where("id = ?", id).
-		Update("processed_at", now).Error
+		Where("id = ? AND claimed_by = ?", id, claimerID).
+		Updates(map[string]interface{}{
+			"claimed_by":    nil,
+			"claimed_until": nil,
+		}).Error
+}
+
+// MarkAsProcessed marca as mensagens informadas como processadas em um único UPDATE. Só são
+// alteradas as mensagens ainda reservadas por claimerID: se o lease expirou e outro dispatcher
+// as reservou, a atualização é ignorada. Retorna o número de mensagens alteradas.
+func (r *GormOutboxRepository) MarkAsProcessed(ctx context.Context, ids []uint, claimerID string) (int64, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	now := time.Now()
+	result := r.db.WithContext(ctx).
+		Model(&entities.OutboxMessage{}).
+		Where("id IN ? AND claimed_by = ?", ids, claimerID).
+		Updates(map[string]interface{}{
+			"processed_at":  now,
+			"status":        entities.StatusProcessed,
+			"claimed_by":    nil,
+			"claimed_until": nil,
+		})
+	return result.RowsAffected, result.Error
+}
+
+// MarkAsFailed incrementa as tentativas, registra o erro e agenda a próxima tentativa, desde que
+// a mensagem ainda esteja reservada por claimerID. Retorna o número de mensagens alteradas.
+func (r *GormOutboxRepository) MarkAsFailed(ctx context.Context, id uint, claimerID, lastError string, nextAttemptAt time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).
+		Model(&entities.OutboxMessage{}).
+		Where("id = ? AND claimed_by = ?", id, claimerID).
+		Updates(map[string]interface{}{
+			"status":          entities.StatusFailed,
+			"attempts":        gorm.Expr("attempts + 1"),
+			"last_error":      lastError,
+			"next_attempt_at": nextAttemptAt,
+			"claimed_by":      nil,
+			"claimed_until":   nil,
+		})
+	return result.RowsAffected, result.Error
+}
+
+// MarkAsDead incrementa as tentativas, registra o erro e estaciona a mensagem como DEAD, desde
+// que ela ainda esteja reservada por claimerID. Retorna o número de mensagens alteradas.
+func (r *GormOutboxRepository) MarkAsDead(ctx context.Context, id uint, claimerID, lastError string) (int64, error) {
+	result := r.db.WithContext(ctx).
+		Model(&entities.OutboxMessage{}).
+		Where("id = ? AND claimed_by = ?", id, claimerID).
+		Updates(map[string]interface{}{
+			"status":          entities.StatusDead,
+			"attempts":        gorm.Expr("attempts + 1"),
+			"last_error":      lastError,
+			"next_attempt_at": nil,
+			"claimed_by":      nil,
+			"claimed_until":   nil,
+		})
+	return result.RowsAffected, result.Error
+}
+
+// GetByStatus retorna mensagens em um determinado status
+func (r *GormOutboxRepository) GetByStatus(ctx context.Context, status string, limit int) ([]entities.OutboxMessage, error) {
+	var messages []entities.OutboxMessage
+	err := r.db.WithContext(ctx).
+		Where("status = ?", status).
+		Order("id ASC").
+		Limit(limit).
+		Find(&messages).Error
+	return messages, err
+}
+
+// pendingScope restringe às mensagens ainda não publicadas que o dispatcher vai tentar (exclui DEAD)
+func pendingScope(db *gorm.DB) *gorm.DB {
+	return db.Model(&entities.OutboxMessage{}).
+		Where("processed_at IS NULL").
+		Where("status IN ?", []string{entities.StatusPending, entities.StatusFailed})
+}
+
+// CountPending retorna a quantidade de mensagens pendentes com um único COUNT(*)
+func (r *GormOutboxRepository) CountPending(ctx context.Context) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).
+		Scopes(pendingScope).
+		Count(&count).Error
+	return count, err
+}
+
+// GetOldestPendingCreatedAt retorna o created_at da mensagem pendente mais antiga (nil se não houver)
+func (r *GormOutboxRepository) GetOldestPendingCreatedAt(ctx context.Context) (*time.Time, error) {
+	var oldest sql.NullTime
+	err := r.db.WithContext(ctx).
+		Scopes(pendingScope).
+		Select("MIN(created_at)").
+		Row().
+		Scan(&oldest)
+	if err != nil {
+		return nil, err
+	}
+	if !oldest.Valid {
+		return nil, nil
+	}
+	return &oldest.Time, nil
+}
+
+// CountByStatus retorna a quantidade de mensagens agrupada por status
+func (r *GormOutboxRepository) CountByStatus(ctx context.Context) (map[string]int64, error) {
+	var rows []struct {
+		Status string
+		Count  int64
+	}
+	err := r.db.WithContext(ctx).
+		Model(&entities.OutboxMessage{}).
+		Select("status, COUNT(*) AS count").
+		Group("status").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		counts[row.Status] = row.Count
+	}
+	return counts, nil
+}
+
+// List retorna mensagens da outbox aplicando os filtros informados
+func (r *GormOutboxRepository) List(ctx context.Context, filter OutboxFilter) ([]entities.OutboxMessage, error) {
+	query := r.db.WithContext(ctx).Model(&entities.OutboxMessage{})
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.Aggregate != "" {
+		query = query.Where("aggregate = ?", filter.Aggregate)
+	}
+	if filter.EventType != "" {
+		query = query.Where("event_type = ?", filter.EventType)
+	}
+
+	var messages []entities.OutboxMessage
+	err := query.
+		Order("id DESC").
+		Limit(filter.Limit).
+		Offset(filter.Offset).
+		Find(&messages).Error
+	return messages, err
+}
+
+// requeueUpdates campos redefinidos ao devolver uma mensagem para a fila
+func requeueUpdates() map[string]interface{} {
+	return map[string]interface{}{
+		"status":          entities.StatusPending,
+		"attempts":        0,
+		"next_attempt_at": nil,
+		"claimed_by":      nil,
+		"claimed_until":   nil,
+	}
+}
+
+// Requeue devolve uma mensagem FAILED ou DEAD para PENDING, zerando as tentativas.
+// Retorna false se a mensagem não existir ou não estiver em um status reenfileirável.
+func (r *GormOutboxRepository) Requeue(ctx context.Context, id uint) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Model(&entities.OutboxMessage{}).
+		Where("id = ? AND status IN ?", id, []string{entities.StatusFailed, entities.StatusDead}).
+		Updates(requeueUpdates())
+	return result.RowsAffected > 0, result.Error
+}
+
+// RequeueByStatus devolve para PENDING todas as mensagens no status informado (FAILED ou DEAD)
+func (r *GormOutboxRepository) RequeueByStatus(ctx context.Context, status string) (int64, error) {
+	result := r.db.WithContext(ctx).
+		Model(&entities.OutboxMessage{}).
+		Where("status = ? AND status IN ?", status, []string{entities.StatusFailed, entities.StatusDead}).
+		Updates(requeueUpdates())
+	return result.RowsAffected, result.Error
+}
+
+// GetProcessedBefore retorna mensagens processadas antes do instante informado, em ordem de ID
+func (r *GormOutboxRepository) GetProcessedBefore(ctx context.Context, before time.Time, limit int) ([]entities.OutboxMessage, error) {
+	var messages []entities.OutboxMessage
+	err := r.db.WithContext(ctx).
+		Where("processed_at IS NOT NULL AND processed_at < ?", before).
+		Order("id ASC").
+		Limit(limit).
+		Find(&messages).Error
+	return messages, err
+}
+
+// DeleteProcessed remove as mensagens informadas, desde que já tenham sido processadas
+func (r *GormOutboxRepository) DeleteProcessed(ctx context.Context, ids []uint) (int64, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	result := r.db.WithContext(ctx).
+		Where("id IN ? AND processed_at IS NOT NULL", ids).
+		Delete(&entities.OutboxMessage{})
+	return result.RowsAffected, result.Error
 }
 
 // GetByID busca uma mensagem por ID
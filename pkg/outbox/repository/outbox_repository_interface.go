@@ -2,13 +2,37 @@ package repository
 
 import (
 	"context"
+	"time"
 	"pkg/outbox/entities"
 )
 
+// OutboxFilter filtros para listagem de mensagens da outbox (campos vazios são ignorados)
+type OutboxFilter struct {
+	Status    string
+	Aggregate string
+	EventType string
+	Limit     int
+	Offset    int
+}
+
 // OutboxRepository interface para operações de outbox
 type OutboxRepository interface {
 	Save(ctx context.Context, message *entities.OutboxMessage) error
 	GetPending(ctx context.Context, limit int) ([]entities.OutboxMessage, error)
-	MarkAsProcessed(ctx context.Context, id uint) error
+	ClaimPending(ctx context.Context, claimerID string, limit int, lease time.Duration) ([]entities.OutboxMessage, error)
+	ClaimPendingOrdered(ctx context.Context, claimerID string, limit int, lease time.Duration) ([]entities.OutboxMessage, error)
+	ReleaseClaim(ctx context.Context, id uint, claimerID string) error
+	MarkAsProcessed(ctx context.Context, ids []uint, claimerID string) (int64, error)
+	MarkAsFailed(ctx context.Context, id uint, claimerID, lastError string, nextAttemptAt time.Time) (int64, error)
+	MarkAsDead(ctx context.Context, id uint, claimerID, lastError string) (int64, error)
+	GetByStatus(ctx context.Context, status string, limit int) ([]entities.OutboxMessage, error)
+	CountByStatus(ctx context.Context) (map[string]int64, error)
+	CountPending(ctx context.Context) (int64, error)
+	GetOldestPendingCreatedAt(ctx context.Context) (*time.Time, error)
+	List(ctx context.Context, filter OutboxFilter) ([]entities.OutboxMessage, error)
+	Requeue(ctx context.Context, id uint) (bool, error)
+	RequeueByStatus(ctx context.Context, status string) (int64, error)
+	GetProcessedBefore(ctx context.Context, before time.Time, limit int) ([]entities.OutboxMessage, error)
+	DeleteProcessed(ctx context.Context, ids []uint) (int64, error)
 	GetByID(ctx context.Context, id uint) (*entities.OutboxMessage, error)
 }
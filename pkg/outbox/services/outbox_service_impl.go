@@ -7,6 +7,7 @@ import (
 	"time"
 	"pkg/outbox/entities"
 	"pkg/outbox/repository"
+	pkgevents "pkg/events"
 
 	"github.com/rs/zerolog/log"
 	"gorm.io/gorm"
@@ -15,27 +16,45 @@ import (
 // OutboxServiceImpl implementação do serviço de outbox
 type OutboxServiceImpl struct {
 	outboxRepo repository.OutboxRepository
+	notify     chan struct{} // Sinaliza ao dispatcher do mesmo processo que há mensagens novas
 }
 
 // NewOutboxService cria um novo serviço de outbox
 func NewOutboxService(outboxRepo repository.OutboxRepository) OutboxService {
 	return &OutboxServiceImpl{
 		outboxRepo: outboxRepo,
+		notify:     make(chan struct{}, 1),
 	}
 }
 
-// CreateMessage cria uma nova mensagem de outbox
-func (s *OutboxServiceImpl) CreateMessage(ctx context.Context, aggregate, eventType string, payload interface{}) (*entities.OutboxMessage, error) {
-	payloadBytes, err := json.Marshal(payload)
-	if err != nil {
-		return nil, fmt.Errorf("erro ao serializar payload: %w", err)
+// Notifications retorna o canal sinalizado quando mensagens novas são confirmadas na outbox
+func (s *OutboxServiceImpl) Notifications() <-chan struct{} {
+	return s.notify
+}
+
+// signal acorda o dispatcher sem bloquear; sinais repetidos antes do consumo se acumulam em um só
+func (s *OutboxServiceImpl) signal() {
+	select {
+	case s.notify <- struct{}{}:
+	default:
 	}
+}
 
-	message := &entities.OutboxMessage{
-		Aggregate: aggregate,
-		EventType: eventType,
-		Payload:   string(payloadBytes),
-		CreatedAt: time.Now(),
+// RunInTransaction executa fn em uma transação e, após o commit, acorda o dispatcher do processo
+func (s *OutboxServiceImpl) RunInTransaction(ctx context.Context, db *gorm.DB, fn func(tx *gorm.DB) error) error {
+	if err := db.WithContext(ctx).Transaction(fn); err != nil {
+		return err
+	}
+
+	s.signal()
+	return nil
+}
+
+// CreateMessage cria uma nova mensagem de outbox
+func (s *OutboxServiceImpl) CreateMessage(ctx context.Context, aggregate, eventType string, payload interface{}, metadata pkgevents.Metadata) (*entities.OutboxMessage, error) {
+	message, err := newMessage(ctx, aggregate, eventType, payload, metadata)
+	if err != nil {
+		return nil, err
 	}
 
 	if err := s.outboxRepo.Save(ctx, message); err != nil {
@@ -48,21 +67,15 @@ func (s *OutboxServiceImpl) CreateMessage(ctx context.Context, aggregate, eventT
 		Str("event_type", eventType).
 		Msg("mensagem criada na outbox")
 
+	s.signal()
 	return message, nil
 }
 
 // CreateMessageInTransaction cria uma nova mensagem de outbox dentro de uma transação
-func (s *OutboxServiceImpl) CreateMessageInTransaction(ctx context.Context, tx interface{}, aggregate, eventType string, payload interface{}) (*entities.OutboxMessage, error) {
-	payloadBytes, err := json.Marshal(payload)
+func (s *OutboxServiceImpl) CreateMessageInTransaction(ctx context.Context, tx interface{}, aggregate, eventType string, payload interface{}, metadata pkgevents.Metadata) (*entities.OutboxMessage, error) {
+	message, err := newMessage(ctx, aggregate, eventType, payload, metadata)
 	if err != nil {
-		return nil, fmt.Errorf("erro ao serializar payload: %w", err)
-	}
-
-	message := &entities.OutboxMessage{
-		Aggregate: aggregate,
-		EventType: eventType,
-		Payload:   string(payloadBytes),
-		CreatedAt: time.Now(),
+		return nil, err
 	}
 
 	// Usa a transação fornecida
@@ -75,6 +88,11 @@ func (s *OutboxServiceImpl) CreateMessageInTransaction(ctx context.Context, tx i
 		return nil, fmt.Errorf("erro ao salvar mensagem na outbox: %w", err)
 	}
 
+	// A mensagem só fica visível ao dispatcher após o commit; fora de uma transação já está confirmada
+	if !afterCommit(gormTx, s.signal) {
+		s.signal()
+	}
+
 	log.Info().
 		Uint("message_id", message.ID).
 		Str("aggregate", aggregate).
@@ -84,14 +102,169 @@ func (s *OutboxServiceImpl) CreateMessageInTransaction(ctx context.Context, tx i
 	return message, nil
 }
 
+// txConnPool é a conexão de uma transação aberta pelo GORM
+type txConnPool interface {
+	gorm.ConnPool
+	gorm.TxCommitter
+}
+
+// afterCommitTx envolve a conexão da transação e executa os callbacks registrados após um
+// commit bem-sucedido; em rollback eles são descartados
+type afterCommitTx struct {
+	txConnPool
+	callbacks []func()
+}
+
+// Commit confirma a transação e então executa os callbacks
+func (t *afterCommitTx) Commit() error {
+	if err := t.txConnPool.Commit(); err != nil {
+		return err
+	}
+
+	for _, callback := range t.callbacks {
+		callback()
+	}
+	return nil
+}
+
+// afterCommit agenda fn para depois do commit da transação tx (o *gorm.DB recebido por
+// db.Transaction). Retorna false se tx não estiver em uma transação.
+func afterCommit(tx *gorm.DB, fn func()) bool {
+	switch conn := tx.Statement.ConnPool.(type) {
+	case *afterCommitTx:
+		conn.callbacks = append(conn.callbacks, fn)
+	case txConnPool:
+		tx.Statement.ConnPool = &afterCommitTx{txConnPool: conn, callbacks: []func(){fn}}
+	default:
+		return false
+	}
+	return true
+}
+
+// newMessage monta a mensagem da outbox com payload e headers serializados.
+// O event_type é sempre propagado e, se ausente nos metadados, o correlation_id
+// é herdado do contexto (requisição HTTP ou evento sendo consumido).
+func newMessage(ctx context.Context, aggregate, eventType string, payload interface{}, metadata pkgevents.Metadata) (*entities.OutboxMessage, error) {
+	payloadBytes, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao serializar payload: %w", err)
+	}
+
+	if metadata.EventType == "" {
+		metadata.EventType = eventType
+	}
+	if metadata.CorrelationID == "" {
+		if ctxMetadata, ok := pkgevents.MetadataFromContext(ctx); ok {
+			metadata.CorrelationID = ctxMetadata.CorrelationID
+		}
+	}
+
+	message := &entities.OutboxMessage{
+		Aggregate:   aggregate,
+		AggregateID: metadata.AggregateID,
+		EventType:   eventType,
+		Payload:     string(payloadBytes),
+		Status:      entities.StatusPending,
+		CreatedAt:   time.Now(),
+	}
+
+	if err := message.SetHeaders(metadata.ToHeaders()); err != nil {
+		return nil, fmt.Errorf("erro ao serializar headers: %w", err)
+	}
+
+	return message, nil
+}
+
 // GetPendingMessages retorna mensagens pendentes de processamento
 func (s *OutboxServiceImpl) GetPendingMessages(ctx context.Context, limit int) ([]entities.OutboxMessage, error) {
 	return s.outboxRepo.GetPending(ctx, limit)
 }
 
-// MarkMessageAsProcessed marca uma mensagem como processada
-func (s *OutboxServiceImpl) MarkMessageAsProcessed(ctx context.Context, id uint) error {
-	return s.outboxRepo.MarkAsProcessed(ctx, id)
+// ClaimPendingMessages reserva um lote de mensagens pendentes para o dispatcher informado
+func (s *OutboxServiceImpl) ClaimPendingMessages(ctx context.Context, claimerID string, limit int, lease time.Duration) ([]entities.OutboxMessage, error) {
+	return s.outboxRepo.ClaimPending(ctx, claimerID, limit, lease)
+}
+
+// ClaimPendingMessagesOrdered reserva mensagens pendentes respeitando a ordem por agregado
+func (s *OutboxServiceImpl) ClaimPendingMessagesOrdered(ctx context.Context, claimerID string, limit int, lease time.Duration) ([]entities.OutboxMessage, error) {
+	return s.outboxRepo.ClaimPendingOrdered(ctx, claimerID, limit, lease)
+}
+
+// ReleaseMessage libera o lease de uma mensagem reservada pelo dispatcher informado
+func (s *OutboxServiceImpl) ReleaseMessage(ctx context.Context, id uint, claimerID string) error {
+	return s.outboxRepo.ReleaseClaim(ctx, id, claimerID)
+}
+
+// MarkMessageAsProcessed marca uma mensagem reservada pelo dispatcher informado como processada
+func (s *OutboxServiceImpl) MarkMessageAsProcessed(ctx context.Context, id uint, claimerID string) (int64, error) {
+	return s.outboxRepo.MarkAsProcessed(ctx, []uint{id}, claimerID)
+}
+
+// MarkMessagesAsProcessed marca um lote de mensagens reservadas pelo dispatcher informado como processadas
+func (s *OutboxServiceImpl) MarkMessagesAsProcessed(ctx context.Context, ids []uint, claimerID string) (int64, error) {
+	return s.outboxRepo.MarkAsProcessed(ctx, ids, claimerID)
+}
+
+// MarkMessageAsFailed registra uma falha de publicação e agenda a próxima tentativa
+func (s *OutboxServiceImpl) MarkMessageAsFailed(ctx context.Context, id uint, claimerID, lastError string, nextAttemptAt time.Time) (int64, error) {
+	return s.outboxRepo.MarkAsFailed(ctx, id, claimerID, lastError, nextAttemptAt)
+}
+
+// MarkMessageAsDead registra a última falha e estaciona a mensagem como DEAD
+func (s *OutboxServiceImpl) MarkMessageAsDead(ctx context.Context, id uint, claimerID, lastError string) (int64, error) {
+	return s.outboxRepo.MarkAsDead(ctx, id, claimerID, lastError)
+}
+
+// GetMessagesByStatus retorna mensagens em um determinado status
+func (s *OutboxServiceImpl) GetMessagesByStatus(ctx context.Context, status string, limit int) ([]entities.OutboxMessage, error) {
+	return s.outboxRepo.GetByStatus(ctx, status, limit)
+}
+
+// GetStatusCounts retorna a quantidade de mensagens por status
+func (s *OutboxServiceImpl) GetStatusCounts(ctx context.Context) (map[string]int64, error) {
+	return s.outboxRepo.CountByStatus(ctx)
+}
+
+// ListMessages lista mensagens da outbox aplicando os filtros informados
+func (s *OutboxServiceImpl) ListMessages(ctx context.Context, filter repository.OutboxFilter) ([]entities.OutboxMessage, error) {
+	return s.outboxRepo.List(ctx, filter)
+}
+
+// RequeueMessage devolve uma mensagem FAILED ou DEAD para a fila de publicação
+func (s *OutboxServiceImpl) RequeueMessage(ctx context.Context, id uint) (bool, error) {
+	requeued, err := s.outboxRepo.Requeue(ctx, id)
+	if err != nil {
+		return false, fmt.Errorf("erro ao reenfileirar mensagem: %w", err)
+	}
+
+	if requeued {
+		log.Info().Uint("message_id", id).Msg("mensagem da outbox reenfileirada")
+	}
+	return requeued, nil
+}
+
+// RequeueMessagesByStatus devolve para a fila todas as mensagens no status informado
+func (s *OutboxServiceImpl) RequeueMessagesByStatus(ctx context.Context, status string) (int64, error) {
+	count, err := s.outboxRepo.RequeueByStatus(ctx, status)
+	if err != nil {
+		return 0, fmt.Errorf("erro ao reenfileirar mensagens: %w", err)
+	}
+
+	log.Info().
+		Str("status", status).
+		Int64("count", count).
+		Msg("mensagens da outbox reenfileiradas")
+	return count, nil
+}
+
+// GetProcessedMessagesBefore retorna mensagens processadas antes do instante informado
+func (s *OutboxServiceImpl) GetProcessedMessagesBefore(ctx context.Context, before time.Time, limit int) ([]entities.OutboxMessage, error) {
+	return s.outboxRepo.GetProcessedBefore(ctx, before, limit)
+}
+
+// DeleteProcessedMessages remove mensagens já processadas
+func (s *OutboxServiceImpl) DeleteProcessedMessages(ctx context.Context, ids []uint) (int64, error) {
+	return s.outboxRepo.DeleteProcessed(ctx, ids)
 }
 
 // GetMessageByID busca uma mensagem por ID
@@ -99,13 +272,16 @@ func (s *OutboxServiceImpl) GetMessageByID(ctx context.Context, id uint) (*entit
 	return s.outboxRepo.GetByID(ctx, id)
 }
 
-// GetPendingCount retorna o número de mensagens pendentes
+// GetPendingCount retorna o número de mensagens pendentes (PENDING e FAILED)
 func (s *OutboxServiceImpl) GetPendingCount(ctx context.Context) (int64, error) {
-	// Para implementar isso, precisaríamos adicionar um método no repositório
-	// Por enquanto, vamos buscar todas e contar
-	messages, err := s.outboxRepo.GetPending(ctx, 10000) // Número alto para pegar todas
-	if err != nil {
+	return s.outboxRepo.CountPending(ctx)
+}
+
+// GetOldestPendingAge retorna há quanto tempo a mensagem pendente mais antiga aguarda (0 se não houver)
+func (s *OutboxServiceImpl) GetOldestPendingAge(ctx context.Context) (time.Duration, error) {
+	oldest, err := s.outboxRepo.GetOldestPendingCreatedAt(ctx)
+	if err != nil || oldest == nil {
 		return 0, err
 	}
-	return int64(len(messages)), nil
+	return time.Since(*oldest), nil
 }
@@ -0,0 +1,103 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+// fakeConnPool é uma conexão fora de transação. Embute a interface para não implementar as consultas.
+type fakeConnPool struct {
+	gorm.ConnPool
+}
+
+// fakeTx é a conexão de uma transação; commitErr simula a falha do commit
+type fakeTx struct {
+	fakeConnPool
+	commitErr  error
+	rolledBack bool
+}
+
+func (t *fakeTx) Commit() error {
+	return t.commitErr
+}
+
+func (t *fakeTx) Rollback() error {
+	t.rolledBack = true
+	return nil
+}
+
+// newTx devolve um *gorm.DB sobre a conexão informada, como o recebido por db.Transaction
+func newTx(conn gorm.ConnPool) *gorm.DB {
+	return &gorm.DB{Config: &gorm.Config{}, Statement: &gorm.Statement{ConnPool: conn}}
+}
+
+// signaled indica se o serviço tem um sinal pendente para o dispatcher, consumindo-o
+func signaled(s *OutboxServiceImpl) bool {
+	select {
+	case <-s.Notifications():
+		return true
+	default:
+		return false
+	}
+}
+
+func TestAfterCommitSignalsOnlyAfterSuccessfulCommit(t *testing.T) {
+	tests := []struct {
+		name       string
+		commitErr  error
+		rollback   bool
+		wantSignal bool
+	}{
+		{name: "commit", wantSignal: true},
+		{name: "commit com erro", commitErr: errors.New("deadlock")},
+		{name: "rollback", rollback: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewOutboxService(nil).(*OutboxServiceImpl)
+			conn := &fakeTx{commitErr: tt.commitErr}
+			tx := newTx(conn)
+
+			// Duas mensagens na mesma transação registram o callback uma vez cada
+			for i := 0; i < 2; i++ {
+				if !afterCommit(tx, s.signal) {
+					t.Fatal("afterCommit = false, esperado true dentro de uma transação")
+				}
+			}
+			if signaled(s) {
+				t.Fatal("dispatcher sinalizado antes do commit")
+			}
+
+			if tt.rollback {
+				tx.Rollback()
+				if !conn.rolledBack {
+					t.Error("rollback não repassado à transação")
+				}
+			} else {
+				tx.Commit()
+			}
+
+			if got := signaled(s); got != tt.wantSignal {
+				t.Errorf("sinalizado = %v, esperado %v", got, tt.wantSignal)
+			}
+			if signaled(s) {
+				t.Error("sinais repetidos não foram agrupados")
+			}
+		})
+	}
+}
+
+func TestAfterCommitOutsideTransaction(t *testing.T) {
+	s := NewOutboxService(nil).(*OutboxServiceImpl)
+	tx := newTx(&fakeConnPool{})
+
+	if afterCommit(tx, s.signal) {
+		t.Error("afterCommit = true, esperado false fora de uma transação")
+	}
+	if _, ok := tx.Statement.ConnPool.(*afterCommitTx); ok {
+		t.Error("conexão fora de transação foi envolvida")
+	}
+}
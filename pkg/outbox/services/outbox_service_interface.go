@@ -2,15 +2,36 @@ package services
 
 import (
 	"context"
+	"time"
 	"pkg/outbox/entities"
+	"pkg/outbox/repository"
+	pkgevents "pkg/events"
+
+	"gorm.io/gorm"
 )
 
 // OutboxService interface para operações de outbox
 type OutboxService interface {
-	CreateMessage(ctx context.Context, aggregate, eventType string, payload interface{}) (*entities.OutboxMessage, error)
-	CreateMessageInTransaction(ctx context.Context, tx interface{}, aggregate, eventType string, payload interface{}) (*entities.OutboxMessage, error)
+	CreateMessage(ctx context.Context, aggregate, eventType string, payload interface{}, metadata pkgevents.Metadata) (*entities.OutboxMessage, error)
+	CreateMessageInTransaction(ctx context.Context, tx interface{}, aggregate, eventType string, payload interface{}, metadata pkgevents.Metadata) (*entities.OutboxMessage, error)
+	RunInTransaction(ctx context.Context, db *gorm.DB, fn func(tx *gorm.DB) error) error
+	Notifications() <-chan struct{}
 	GetPendingMessages(ctx context.Context, limit int) ([]entities.OutboxMessage, error)
-	MarkMessageAsProcessed(ctx context.Context, id uint) error
+	ClaimPendingMessages(ctx context.Context, claimerID string, limit int, lease time.Duration) ([]entities.OutboxMessage, error)
+	ClaimPendingMessagesOrdered(ctx context.Context, claimerID string, limit int, lease time.Duration) ([]entities.OutboxMessage, error)
+	ReleaseMessage(ctx context.Context, id uint, claimerID string) error
+	MarkMessageAsProcessed(ctx context.Context, id uint, claimerID string) (int64, error)
+	MarkMessagesAsProcessed(ctx context.Context, ids []uint, claimerID string) (int64, error)
+	MarkMessageAsFailed(ctx context.Context, id uint, claimerID, lastError string, nextAttemptAt time.Time) (int64, error)
+	MarkMessageAsDead(ctx context.Context, id uint, claimerID, lastError string) (int64, error)
+	GetMessagesByStatus(ctx context.Context, status string, limit int) ([]entities.OutboxMessage, error)
+	GetStatusCounts(ctx context.Context) (map[string]int64, error)
+	ListMessages(ctx context.Context, filter repository.OutboxFilter) ([]entities.OutboxMessage, error)
+	RequeueMessage(ctx context.Context, id uint) (bool, error)
+	RequeueMessagesByStatus(ctx context.Context, status string) (int64, error)
+	GetProcessedMessagesBefore(ctx context.Context, before time.Time, limit int) ([]entities.OutboxMessage, error)
+	DeleteProcessedMessages(ctx context.Context, ids []uint) (int64, error)
 	GetMessageByID(ctx context.Context, id uint) (*entities.OutboxMessage, error)
 	GetPendingCount(ctx context.Context) (int64, error)
+	GetOldestPendingAge(ctx context.Context) (time.Duration, error)
 }
@@ -1,17 +1,23 @@
 package main
 
 import (
-	"context"
-	"time"
+	"fmt"
 	"order-consumer/internal/domain/entities"
 	"order-consumer/internal/repo"
+	pkgbroker "pkg/broker"
 	pkgconfig "pkg/config"
+	pkghttp "pkg/http"
 	pkgkafka "pkg/kafka"
+	pkglifecycle "pkg/lifecycle"
 	pkglog "pkg/log"
+	pkgoutboxadmin "pkg/outbox/admin"
 	pkgoutboxdispatcher "pkg/outbox/dispatcher"
+	pkgoutboxmetrics "pkg/outbox/metrics"
+	pkgoutboxjanitor "pkg/outbox/janitor"
 	pkgoutboxrepo "pkg/outbox/repository"
 	pkgoutboxservices "pkg/outbox/services"
 
+	"github.com/gin-gonic/gin"
 	"github.com/rs/zerolog/log"
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
@@ -31,11 +37,15 @@ func main() {
 		Str("service", "order-consumer").
 		Msg("iniciando order-consumer")
 	
+	// Ciclo de vida: desligamento gracioso em SIGINT/SIGTERM
+	app := pkglifecycle.New(config.GetShutdownTimeout())
+	
 	// Conecta ao MySQL
 	db, err := gorm.Open(mysql.Open(config.MySQLDSN), &gorm.Config{})
 	if err != nil {
 		log.Fatal().Err(err).Msg("erro ao conectar ao MySQL")
 	}
+	app.OnClose("mysql", pkglifecycle.CloseGorm(db))
 	
 	// Auto-migra tabelas
 	if err := db.AutoMigrate(&entities.Order{}, &entities.OrderProduct{}); err != nil {
@@ -49,19 +59,69 @@ func main() {
 	outboxRepo := pkgoutboxrepo.NewGormOutboxRepository(db)
 	outboxService := pkgoutboxservices.NewOutboxService(outboxRepo)
 	
-	// Inicializa Kafka producer
-	kafkaProducer := pkgkafka.NewProducer(config.GetKafkaBrokers())
-	defer kafkaProducer.Close()
+	// Métricas de backlog da outbox (pendentes e idade da mais antiga)
+	if err := pkgoutboxmetrics.RegisterBacklogCollector(outboxService); err != nil {
+		log.Fatal().Err(err).Msg("erro ao registrar métricas da outbox")
+	}
+	
+	// Transporte de mensagens (BROKER_BACKEND: kafka, nats ou memory)
+	messageBroker, err := pkgbroker.New(config)
+	if err != nil {
+		log.Fatal().Err(err).Msg("erro ao inicializar o broker de mensagens")
+	}
+	app.OnClose("broker", pkglifecycle.Closer(messageBroker))
+	
+	// Tópicos declarados em pkg/kafka (partições, retenção, retry e DLQ): cria os ausentes e
+	// interrompe a inicialização se algum divergir (corrija com make migrate-topics)
+	if err := pkgbroker.EnsureTopics(app.Context(), config); err != nil {
+		log.Fatal().Err(err).Msg("erro ao provisionar os tópicos do Kafka")
+	}
+	
+	// Inicializa producer
+	kafkaProducer := pkgkafka.NewProducerWithBroker(messageBroker)
+	app.OnClose("kafka-producer", pkglifecycle.Closer(kafkaProducer))
 	
 	// Inicializa outbox dispatcher
-	outboxDispatcher := pkgoutboxdispatcher.NewOutboxDispatcher(outboxService, kafkaProducer, time.Second)
+	outboxDispatcher := pkgoutboxdispatcher.NewOutboxDispatcher(outboxService, kafkaProducer, config.GetOutboxPollInterval())
+	outboxDispatcher.SetMaxInterval(config.GetOutboxMaxPollInterval())
+	outboxDispatcher.SetStrictOrdering(config.OutboxStrictOrdering)
+	outboxDispatcher.SetLeaseDuration(config.GetOutboxLeaseDuration())
+	outboxDispatcher.SetRetryPolicy(config.OutboxMaxAttempts, config.GetOutboxBackoffBase(), config.GetOutboxBackoffMax())
 	
-	// Inicia dispatcher em background
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
-	go outboxDispatcher.Start(ctx)
+	// Configura roteamento de tópicos da outbox
+	topicRules, err := pkgoutboxdispatcher.ParseTopicRules(config.OutboxTopicRoutes)
+	if err != nil {
+		log.Fatal().Err(err).Msg("erro ao interpretar OUTBOX_TOPIC_ROUTES")
+	}
+	outboxDispatcher.SetTopicRouter(pkgoutboxdispatcher.NewTopicRouter(config.OutboxTopicPrefix, topicRules))
+	
+	// Inicializa limpeza da outbox (retenção e arquivamento)
+	outboxJanitor := pkgoutboxjanitor.NewOutboxJanitor(outboxService, pkgoutboxjanitor.Config{
+		Retention:  config.GetOutboxRetention(),
+		Interval:   config.GetOutboxJanitorInterval(),
+		BatchSize:  config.OutboxJanitorBatchSize,
+		ArchiveDir: config.OutboxArchiveDir,
+	})
+	
+	// Inicia dispatcher e limpeza em background
+	app.Start("outbox-dispatcher", outboxDispatcher.Start)
+	app.Start("outbox-janitor", outboxJanitor.Start)
 	
-	// Mantém o consumer rodando
+	// Servidor HTTP administrativo (healthcheck e outbox)
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	router.Use(pkghttp.Logger())
+	router.Use(pkghttp.Recovery())
+	router.GET("/healthz", pkghttp.HealthCheck())
+	router.GET("/metrics", pkghttp.Metrics())
+	admin := router.Group("", pkghttp.AdminAuth(config.AdminToken))
+	pkgoutboxadmin.SetupRoutes(admin, pkgoutboxadmin.NewOutboxAdminController(outboxService, outboxDispatcher))
+	
+	app.Serve("admin", fmt.Sprintf(":%d", config.Port), router)
+	
+	// Mantém o consumer rodando até SIGINT/SIGTERM
 	log.Info().Msg("order-consumer iniciado")
-	select {} // Bloqueia indefinidamente
+	if err := app.Wait(); err != nil {
+		log.Fatal().Err(err).Msg("erro no desligamento do consumer")
+	}
 }
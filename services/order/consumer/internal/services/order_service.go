@@ -3,6 +3,7 @@ package services
 import (
 	"context"
 	"fmt"
+	"strconv"
 	"order-consumer/internal/domain/entities"
 	"order-consumer/internal/repo"
 	pkgoutboxservices "pkg/outbox/services"
@@ -29,7 +30,7 @@ func NewOrderService(orderRepo repo.OrderRepository, outboxService pkgoutboxserv
 
 // CreateOrderWithEvent cria um pedido e grava o evento na outbox na mesma transação
 func (s *OrderService) CreateOrderWithEvent(ctx context.Context, order *entities.Order) error {
-	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+	return s.outboxService.RunInTransaction(ctx, s.db, func(tx *gorm.DB) error {
 		// Cria o pedido usando o repositório
 		if err := s.orderRepo.Create(ctx, order); err != nil {
 			return err
@@ -58,7 +59,9 @@ func (s *OrderService) CreateOrderWithEvent(ctx context.Context, order *entities
 		}
 		
 		// Cria a mensagem da outbox usando o serviço dentro da transação
-		_, err := s.outboxService.CreateMessageInTransaction(ctx, tx, "order", "order.created", event)
+		_, err := s.outboxService.CreateMessageInTransaction(ctx, tx, "order", "order.created", event, pkgevents.Metadata{
+			AggregateID: strconv.FormatUint(uint64(order.ID), 10),
+		})
 		if err != nil {
 			return err
 		}
@@ -69,7 +72,7 @@ func (s *OrderService) CreateOrderWithEvent(ctx context.Context, order *entities
 
 // PayOrderWithEvent paga um pedido e grava o evento na outbox na mesma transação
 func (s *OrderService) PayOrderWithEvent(ctx context.Context, orderID uint) error {
-	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+	return s.outboxService.RunInTransaction(ctx, s.db, func(tx *gorm.DB) error {
 		// Verifica se o pedido existe e está no status correto usando o repositório
 		order, err := s.orderRepo.GetByID(ctx, orderID)
 		if err != nil {
@@ -92,7 +95,9 @@ func (s *OrderService) PayOrderWithEvent(ctx context.Context, orderID uint) erro
 		}
 		
 		// Cria a mensagem da outbox usando o serviço dentro da transação
-		_, err = s.outboxService.CreateMessageInTransaction(ctx, tx, "order", "order.paid", event)
+		_, err = s.outboxService.CreateMessageInTransaction(ctx, tx, "order", "order.paid", event, pkgevents.Metadata{
+			AggregateID: strconv.FormatUint(uint64(orderID), 10),
+		})
 		if err != nil {
 			return err
 		}
@@ -103,7 +108,7 @@ func (s *OrderService) PayOrderWithEvent(ctx context.Context, orderID uint) erro
 
 // CancelOrderWithEvent cancela um pedido e grava o evento na outbox na mesma transação
 func (s *OrderService) CancelOrderWithEvent(ctx context.Context, orderID uint, reason string) error {
-	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+	return s.outboxService.RunInTransaction(ctx, s.db, func(tx *gorm.DB) error {
 		// Verifica se o pedido existe e pode ser cancelado usando o repositório
 		order, err := s.orderRepo.GetByID(ctx, orderID)
 		if err != nil {
@@ -131,7 +136,9 @@ func (s *OrderService) CancelOrderWithEvent(ctx context.Context, orderID uint, r
 		}
 		
 		// Cria a mensagem da outbox usando o serviço dentro da transação
-		_, err = s.outboxService.CreateMessageInTransaction(ctx, tx, "order", "order.canceled", event)
+		_, err = s.outboxService.CreateMessageInTransaction(ctx, tx, "order", "order.canceled", event, pkgevents.Metadata{
+			AggregateID: strconv.FormatUint(uint64(orderID), 10),
+		})
 		if err != nil {
 			return err
 		}
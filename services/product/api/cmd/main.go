@@ -7,8 +7,14 @@ import (
 	"product-api/internal/domain/entities"
 	"product-api/internal/services"
 	"product-api/internal/repo"
+	pkgbroker "pkg/broker"
 	pkgconfig "pkg/config"
+	pkglifecycle "pkg/lifecycle"
 	pkglog "pkg/log"
+	pkgkafka "pkg/kafka"
+	pkgoutboxadmin "pkg/outbox/admin"
+	pkgoutboxdispatcher "pkg/outbox/dispatcher"
+	pkgoutboxmetrics "pkg/outbox/metrics"
 	pkgoutboxrepo "pkg/outbox/repository"
 	pkgoutboxservices "pkg/outbox/services"
 	pkghttp "pkg/http"
@@ -34,11 +40,15 @@ func main() {
 		Int("port", config.Port).
 		Msg("iniciando product-api")
 	
+	// Ciclo de vida: desligamento gracioso em SIGINT/SIGTERM
+	app := pkglifecycle.New(config.GetShutdownTimeout())
+	
 	// Conecta ao MySQL
 	db, err := gorm.Open(mysql.Open(config.MySQLDSN), &gorm.Config{})
 	if err != nil {
 		log.Fatal().Err(err).Msg("erro ao conectar ao MySQL")
 	}
+	app.OnClose("mysql", pkglifecycle.CloseGorm(db))
 	
 	// Auto-migra tabelas
 	if err := db.AutoMigrate(&entities.Product{}); err != nil {
@@ -52,9 +62,48 @@ func main() {
 	outboxRepo := pkgoutboxrepo.NewGormOutboxRepository(db)
 	outboxService := pkgoutboxservices.NewOutboxService(outboxRepo)
 	
+	// Métricas de backlog da outbox (pendentes e idade da mais antiga)
+	if err := pkgoutboxmetrics.RegisterBacklogCollector(outboxService); err != nil {
+		log.Fatal().Err(err).Msg("erro ao registrar métricas da outbox")
+	}
+	
 	// Inicializa serviços
 	productService := services.NewProductService(productRepo, outboxService, db)
 	
+	// Dispatcher embutido (padrão; desative com OUTBOX_DISPATCHER_ENABLED=false): publica logo após o commit
+	var outboxDispatcher pkgoutboxdispatcher.OutboxDispatcher
+	if config.OutboxDispatcherEnabled {
+		// Transporte de mensagens (BROKER_BACKEND: kafka, nats ou memory)
+		messageBroker, err := pkgbroker.New(config)
+		if err != nil {
+			log.Fatal().Err(err).Msg("erro ao inicializar o broker de mensagens")
+		}
+		app.OnClose("broker", pkglifecycle.Closer(messageBroker))
+		
+		// Tópicos declarados em pkg/kafka (partições, retenção, retry e DLQ): cria os ausentes e
+		// interrompe a inicialização se algum divergir (corrija com make migrate-topics)
+		if err := pkgbroker.EnsureTopics(app.Context(), config); err != nil {
+			log.Fatal().Err(err).Msg("erro ao provisionar os tópicos do Kafka")
+		}
+		
+		kafkaProducer := pkgkafka.NewProducerWithBroker(messageBroker)
+		app.OnClose("kafka-producer", pkglifecycle.Closer(kafkaProducer))
+		
+		outboxDispatcher = pkgoutboxdispatcher.NewOutboxDispatcher(outboxService, kafkaProducer, config.GetOutboxPollInterval())
+		outboxDispatcher.SetMaxInterval(config.GetOutboxMaxPollInterval())
+		outboxDispatcher.SetStrictOrdering(config.OutboxStrictOrdering)
+		outboxDispatcher.SetLeaseDuration(config.GetOutboxLeaseDuration())
+		outboxDispatcher.SetRetryPolicy(config.OutboxMaxAttempts, config.GetOutboxBackoffBase(), config.GetOutboxBackoffMax())
+		
+		topicRules, err := pkgoutboxdispatcher.ParseTopicRules(config.OutboxTopicRoutes)
+		if err != nil {
+			log.Fatal().Err(err).Msg("erro ao interpretar OUTBOX_TOPIC_ROUTES")
+		}
+		outboxDispatcher.SetTopicRouter(pkgoutboxdispatcher.NewTopicRouter(config.OutboxTopicPrefix, topicRules))
+		
+		app.Start("outbox-dispatcher", outboxDispatcher.Start)
+	}
+	
 	// Configura Gin
 	gin.SetMode(gin.ReleaseMode)
 	router := gin.New()
@@ -62,9 +111,11 @@ func main() {
 	// Middlewares
 	router.Use(pkghttp.Logger())
 	router.Use(pkghttp.Recovery())
+	router.Use(pkghttp.CorrelationID())
 	
 	// Healthcheck
 	router.GET("/healthz", pkghttp.HealthCheck())
+	router.GET("/metrics", pkghttp.Metrics())
 	
 	// Configura controllers
 	productController := controllers.NewProductController(productService)
@@ -72,9 +123,13 @@ func main() {
 	// Configura rotas
 	routes.SetupProductRoutes(router, productController)
 	
-	// Inicia servidor
-	log.Info().Msg("servidor iniciado")
-	if err := router.Run(fmt.Sprintf(":%d", config.Port)); err != nil {
-		log.Fatal().Err(err).Msg("erro ao iniciar servidor")
+	// Rotas administrativas da outbox (exigem ADMIN_TOKEN)
+	admin := router.Group("", pkghttp.AdminAuth(config.AdminToken))
+	pkgoutboxadmin.SetupRoutes(admin, pkgoutboxadmin.NewOutboxAdminController(outboxService, outboxDispatcher))
+	
+	// Inicia servidor e aguarda SIGINT/SIGTERM
+	app.Serve("http", fmt.Sprintf(":%d", config.Port), router)
+	if err := app.Wait(); err != nil {
+		log.Fatal().Err(err).Msg("erro no desligamento do servidor")
 	}
 }
@@ -41,6 +41,9 @@ func (c *OrderConsumer) HandleOrderCreated(ctx context.Context, message []byte)
 			Uint("order_id", event.Order.ID).
 			Msg("processando evento order.created")
 		
+		// Eventos derivados usam a chave do pedido, como os publicados pela outbox do order-service
+		orderKey := []byte(fmt.Sprintf("order:%d", event.Order.ID))
+		
 		// Processa cada item do pedido
 		for _, item := range event.Order.Items {
 			// Tenta reservar estoque
@@ -58,7 +61,7 @@ func (c *OrderConsumer) HandleOrderCreated(ctx context.Context, message []byte)
 					Reason:    fmt.Sprintf("Estoque insuficiente para produto %d", item.ProductID),
 				}
 				
-				if err := c.kafkaProducer.PublishEvent(ctx, "order.canceled", cancelEvent); err != nil {
+				if err := c.kafkaProducer.PublishEventWithKey(ctx, "order.canceled", orderKey, cancelEvent); err != nil {
 					log.Error().Err(err).Msg("erro ao publicar evento de cancelamento")
 				}
 				
@@ -73,7 +76,7 @@ func (c *OrderConsumer) HandleOrderCreated(ctx context.Context, message []byte)
 				Quantity:  item.Quantity,
 			}
 			
-			if err := c.kafkaProducer.PublishEvent(ctx, "stock.reserved", stockEvent); err != nil {
+			if err := c.kafkaProducer.PublishEventWithKey(ctx, "stock.reserved", orderKey, stockEvent); err != nil {
 				log.Error().Err(err).Msg("erro ao publicar evento de estoque reservado")
 				return err
 			}
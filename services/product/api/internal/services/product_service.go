@@ -3,6 +3,7 @@ package services
 import (
 	"context"
 	"fmt"
+	"strconv"
 	"product-api/internal/domain/entities"
 	"product-api/internal/repo"
 	pkgoutboxservices "pkg/outbox/services"
@@ -29,7 +30,7 @@ func NewProductService(productRepo repo.ProductRepository, outboxService pkgoutb
 
 // CreateProductWithEvent cria um produto e grava o evento na outbox na mesma transação
 func (s *ProductService) CreateProductWithEvent(ctx context.Context, product *entities.Product) error {
-	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+	return s.outboxService.RunInTransaction(ctx, s.db, func(tx *gorm.DB) error {
 		// Cria o produto usando o repositório
 		if err := s.productRepo.Create(ctx, product); err != nil {
 			return err
@@ -47,7 +48,9 @@ func (s *ProductService) CreateProductWithEvent(ctx context.Context, product *en
 		}
 		
 		// Cria a mensagem da outbox usando o serviço dentro da transação
-		_, err := s.outboxService.CreateMessageInTransaction(ctx, tx, "product", "product.created", event)
+		_, err := s.outboxService.CreateMessageInTransaction(ctx, tx, "product", "product.created", event, pkgevents.Metadata{
+			AggregateID: strconv.FormatUint(uint64(product.ID), 10),
+		})
 		if err != nil {
 			return err
 		}
@@ -58,7 +61,7 @@ func (s *ProductService) CreateProductWithEvent(ctx context.Context, product *en
 
 // UpdateProductWithEvent atualiza um produto e grava o evento na outbox na mesma transação
 func (s *ProductService) UpdateProductWithEvent(ctx context.Context, productID uint, updates map[string]interface{}) error {
-	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+	return s.outboxService.RunInTransaction(ctx, s.db, func(tx *gorm.DB) error {
 		// Busca o produto atual usando o repositório
 		product, err := s.productRepo.GetByID(ctx, productID)
 		if err != nil {
@@ -88,7 +91,9 @@ func (s *ProductService) UpdateProductWithEvent(ctx context.Context, productID u
 		}
 		
 		// Cria a mensagem da outbox usando o serviço dentro da transação
-		_, err = s.outboxService.CreateMessageInTransaction(ctx, tx, "product", "product.updated", event)
+		_, err = s.outboxService.CreateMessageInTransaction(ctx, tx, "product", "product.updated", event, pkgevents.Metadata{
+			AggregateID: strconv.FormatUint(uint64(updatedProduct.ID), 10),
+		})
 		if err != nil {
 			return err
 		}
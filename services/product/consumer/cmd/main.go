@@ -1,19 +1,26 @@
 package main
 
 import (
-	"context"
-	"time"
+	"fmt"
 	"product-consumer/internal/consumer"
 	"product-consumer/internal/domain/entities"
 	"product-consumer/internal/repo"
+	pkgbroker "pkg/broker"
 	pkgconfig "pkg/config"
+	pkghttp "pkg/http"
 	pkgkafka "pkg/kafka"
+	pkgkafkaadmin "pkg/kafka/admin"
+	pkglifecycle "pkg/lifecycle"
 	pkglog "pkg/log"
+	pkgoutboxadmin "pkg/outbox/admin"
 	pkgoutboxdispatcher "pkg/outbox/dispatcher"
+	pkgoutboxmetrics "pkg/outbox/metrics"
+	pkgoutboxjanitor "pkg/outbox/janitor"
 	pkgoutboxrepo "pkg/outbox/repository"
 	pkgoutboxservices "pkg/outbox/services"
 	pkgidempotency "pkg/idempotency"
 
+	"github.com/gin-gonic/gin"
 	"github.com/rs/zerolog/log"
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
@@ -33,11 +40,15 @@ func main() {
 		Str("service", "product-consumer").
 		Msg("iniciando product-consumer")
 	
+	// Ciclo de vida: desligamento gracioso em SIGINT/SIGTERM
+	app := pkglifecycle.New(config.GetShutdownTimeout())
+	
 	// Conecta ao MySQL
 	db, err := gorm.Open(mysql.Open(config.MySQLDSN), &gorm.Config{})
 	if err != nil {
 		log.Fatal().Err(err).Msg("erro ao conectar ao MySQL")
 	}
+	app.OnClose("mysql", pkglifecycle.CloseGorm(db))
 	
 	// Auto-migra tabelas
 	if err := db.AutoMigrate(&entities.Product{}); err != nil {
@@ -51,42 +62,112 @@ func main() {
 	outboxRepo := pkgoutboxrepo.NewGormOutboxRepository(db)
 	outboxService := pkgoutboxservices.NewOutboxService(outboxRepo)
 	
-	// Inicializa Kafka producer
-	kafkaProducer := pkgkafka.NewProducer(config.GetKafkaBrokers())
-	defer kafkaProducer.Close()
+	// Métricas de backlog da outbox (pendentes e idade da mais antiga)
+	if err := pkgoutboxmetrics.RegisterBacklogCollector(outboxService); err != nil {
+		log.Fatal().Err(err).Msg("erro ao registrar métricas da outbox")
+	}
+	
+	// Transporte de mensagens (BROKER_BACKEND: kafka, nats ou memory)
+	messageBroker, err := pkgbroker.New(config)
+	if err != nil {
+		log.Fatal().Err(err).Msg("erro ao inicializar o broker de mensagens")
+	}
+	app.OnClose("broker", pkglifecycle.Closer(messageBroker))
+	
+	// Inicializa producer
+	kafkaProducer := pkgkafka.NewProducerWithBroker(messageBroker)
+	app.OnClose("kafka-producer", pkglifecycle.Closer(kafkaProducer))
 	
 	// Inicializa outbox dispatcher
-	outboxDispatcher := pkgoutboxdispatcher.NewOutboxDispatcher(outboxService, kafkaProducer, time.Second)
+	outboxDispatcher := pkgoutboxdispatcher.NewOutboxDispatcher(outboxService, kafkaProducer, config.GetOutboxPollInterval())
+	outboxDispatcher.SetMaxInterval(config.GetOutboxMaxPollInterval())
+	outboxDispatcher.SetStrictOrdering(config.OutboxStrictOrdering)
+	outboxDispatcher.SetLeaseDuration(config.GetOutboxLeaseDuration())
+	outboxDispatcher.SetRetryPolicy(config.OutboxMaxAttempts, config.GetOutboxBackoffBase(), config.GetOutboxBackoffMax())
+	
+	// Configura roteamento de tópicos da outbox
+	topicRules, err := pkgoutboxdispatcher.ParseTopicRules(config.OutboxTopicRoutes)
+	if err != nil {
+		log.Fatal().Err(err).Msg("erro ao interpretar OUTBOX_TOPIC_ROUTES")
+	}
+	outboxDispatcher.SetTopicRouter(pkgoutboxdispatcher.NewTopicRouter(config.OutboxTopicPrefix, topicRules))
+	
+	// Inicializa limpeza da outbox (retenção e arquivamento)
+	outboxJanitor := pkgoutboxjanitor.NewOutboxJanitor(outboxService, pkgoutboxjanitor.Config{
+		Retention:  config.GetOutboxRetention(),
+		Interval:   config.GetOutboxJanitorInterval(),
+		BatchSize:  config.OutboxJanitorBatchSize,
+		ArchiveDir: config.OutboxArchiveDir,
+	})
 	
 	// Inicializa idempotência
 	idempotencyRepo := pkgidempotency.NewGormRepository(db)
 	idempotencyHandler := pkgidempotency.NewHandler(idempotencyRepo, "product-consumer")
 	
 	// Inicializa consumidores
-	orderConsumer := consumer.NewOrderConsumer(productRepo, kafkaProducer, idempotencyHandler)
-	
-	// Inicia consumidores em background
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
-	
-	// Consumidor de order.created
-	go func() {
-		consumer := pkgkafka.NewConsumer(config.GetKafkaBrokers(), "order.created", "product-consumer", kafkaProducer)
-		defer consumer.Close()
-		consumer.Consume(ctx, orderConsumer.HandleOrderCreated)
-	}()
-	
-	// Consumidor de order.canceled
-	go func() {
-		consumer := pkgkafka.NewConsumer(config.GetKafkaBrokers(), "order.canceled", "product-consumer", kafkaProducer)
-		defer consumer.Close()
-		consumer.Consume(ctx, orderConsumer.HandleOrderCanceled)
-	}()
-	
-	// Inicia dispatcher em background
-	go outboxDispatcher.Start(ctx)
-	
-	// Mantém o consumer rodando
+	orderConsumer := consumer.NewOrderConsumer(productRepo, kafkaProducer)
+	
+	// Tópicos de retry dos consumidores (<tópico>.<grupo>.retry.<atraso>)
+	retryDelays, err := pkgkafka.ParseRetryDelays(config.KafkaRetryDelays)
+	if err != nil {
+		log.Fatal().Err(err).Msg("erro ao interpretar KAFKA_RETRY_DELAYS")
+	}
+	
+	// Circuit breaker: com a dependência fora do ar, o consumo é suspenso (sem commit) em vez de
+	// esgotar os retries; também permite pausa manual pela API administrativa
+	consumerBreaker := pkgkafka.NewCircuitBreaker(pkgkafka.CircuitBreakerConfig{
+		FailureThreshold: config.KafkaCircuitFailureThreshold,
+		OpenTimeout:      config.GetKafkaCircuitOpenTimeout(),
+		MaxProbes:        config.KafkaCircuitMaxProbes,
+	})
+	
+	// Um único consumer group lê todos os tópicos; cada tópico é roteado para seu handler
+	kafkaRouter := pkgkafka.NewRouterWithBroker(messageBroker, "product-consumer", kafkaProducer)
+	kafkaRouter.SetRetryDelays(retryDelays)
+	kafkaRouter.SetWorkers(config.KafkaConsumerWorkers)
+	kafkaRouter.SetCircuitBreaker(consumerBreaker)
+	// Middlewares aplicados a todos os handlers: rastreamento, log, métricas, recuperação de
+	// panic, timeout e idempotência pelo event_id
+	kafkaRouter.Use(
+		pkgkafka.Tracing(),
+		pkgkafka.Logging(),
+		pkgkafka.Metrics(),
+		pkgkafka.Recovery(),
+		pkgkafka.Timeout(config.GetKafkaHandlerTimeout()),
+		pkgkafka.Idempotency(idempotencyHandler),
+	)
+	kafkaRouter.Handle("order.created", orderConsumer.HandleOrderCreated)
+	kafkaRouter.Handle("order.canceled", orderConsumer.HandleOrderCanceled)
+	
+	// Tópicos declarados em pkg/kafka e os lidos pelo Router, com o retry de cada rota: cria os
+	// ausentes e interrompe a inicialização se algum divergir (corrija com make migrate-topics)
+	if err := pkgbroker.EnsureTopics(app.Context(), config, kafkaRouter); err != nil {
+		log.Fatal().Err(err).Msg("erro ao provisionar os tópicos do Kafka")
+	}
+	
+	// Inicia consumo em background; no desligamento, a mensagem em processamento é concluída
+	app.Go("kafka-router", kafkaRouter.Run)
+	
+	// Inicia dispatcher e limpeza em background
+	app.Start("outbox-dispatcher", outboxDispatcher.Start)
+	app.Start("outbox-janitor", outboxJanitor.Start)
+	
+	// Servidor HTTP administrativo (healthcheck, outbox e consumo)
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	router.Use(pkghttp.Logger())
+	router.Use(pkghttp.Recovery())
+	router.GET("/healthz", pkghttp.HealthCheck())
+	router.GET("/metrics", pkghttp.Metrics())
+	admin := router.Group("", pkghttp.AdminAuth(config.AdminToken))
+	pkgoutboxadmin.SetupRoutes(admin, pkgoutboxadmin.NewOutboxAdminController(outboxService, outboxDispatcher))
+	pkgkafkaadmin.SetupRoutes(admin, pkgkafkaadmin.NewConsumerAdminController(consumerBreaker))
+	
+	app.Serve("admin", fmt.Sprintf(":%d", config.Port), router)
+	
+	// Mantém o consumer rodando até SIGINT/SIGTERM
 	log.Info().Msg("product-consumer iniciado")
-	select {} // Bloqueia indefinidamente
+	if err := app.Wait(); err != nil {
+		log.Fatal().Err(err).Msg("erro no desligamento do consumer")
+	}
 }
@@ -3,28 +3,28 @@ package consumer
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"product-consumer/internal/domain/entities"
 	"product-consumer/internal/repo"
 	pkgkafka "pkg/kafka"
 	pkgevents "pkg/events"
-	pkgidempotency "pkg/idempotency"
 
 	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
 )
 
 // OrderConsumer consumidor de eventos de pedido
 type OrderConsumer struct {
 	productRepo    repo.ProductRepository
 	kafkaProducer  *pkgkafka.Producer
-	idempotencyHandler *pkgidempotency.Handler
 }
 
 // NewOrderConsumer cria um novo consumidor de pedidos
-func NewOrderConsumer(productRepo repo.ProductRepository, kafkaProducer *pkgkafka.Producer, idempotencyHandler *pkgidempotency.Handler) *OrderConsumer {
+func NewOrderConsumer(productRepo repo.ProductRepository, kafkaProducer *pkgkafka.Producer) *OrderConsumer {
 	return &OrderConsumer{
 		productRepo:    productRepo,
 		kafkaProducer:  kafkaProducer,
-		idempotencyHandler: idempotencyHandler,
 	}
 }
 
@@ -32,80 +32,88 @@ func NewOrderConsumer(productRepo repo.ProductRepository, kafkaProducer *pkgkafk
 func (c *OrderConsumer) HandleOrderCreated(ctx context.Context, message []byte) error {
 	var event pkgevents.OrderCreated
 	if err := json.Unmarshal(message, &event); err != nil {
-		return fmt.Errorf("erro ao deserializar evento: %w", err)
+		// Payload inválido não se resolve com novas tentativas: vai direto para a DLQ
+		return pkgkafka.Permanent(fmt.Errorf("erro ao deserializar evento: %w", err))
 	}
 	
-	return c.idempotencyHandler.ProcessWithIdempotency(ctx, event.EventID, func() error {
-		log.Info().
-			Str("event_id", event.EventID).
-			Uint("order_id", event.Order.ID).
-			Msg("processando evento order.created")
-		
-		// Processa cada item do pedido
-		for _, item := range event.Order.Items {
-			// Tenta reservar estoque
-			if err := c.productRepo.ReserveStock(ctx, item.ProductID, item.Quantity); err != nil {
-				log.Error().
-					Err(err).
-					Uint("product_id", item.ProductID).
-					Int("quantity", item.Quantity).
-					Msg("erro ao reservar estoque")
-				
-				// Publica evento de cancelamento
-				cancelEvent := pkgevents.OrderCanceled{
-					BaseEvent: pkgevents.NewBaseEvent(),
-					OrderID:   event.Order.ID,
-					Reason:    fmt.Sprintf("Estoque insuficiente para produto %d", item.ProductID),
-				}
-				
-				if err := c.kafkaProducer.PublishEvent(ctx, "order.canceled", cancelEvent); err != nil {
-					log.Error().Err(err).Msg("erro ao publicar evento de cancelamento")
-				}
-				
-				return err
+	log.Info().
+		Str("event_id", event.EventID).
+		Uint("order_id", event.Order.ID).
+		Msg("processando evento order.created")
+	
+	// Eventos derivados usam a chave do pedido, como os publicados pela outbox do order-service
+	orderKey := []byte(fmt.Sprintf("order:%d", event.Order.ID))
+	
+	// Processa cada item do pedido
+	for _, item := range event.Order.Items {
+		// Tenta reservar estoque
+		if err := c.productRepo.ReserveStock(ctx, item.ProductID, item.Quantity); err != nil {
+			// Falha transitória (ex: banco indisponível): o evento é reprocessado pelo retry
+			if !errors.Is(err, entities.ErrInsufficientStock) && !errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("erro ao reservar estoque do produto %d: %w", item.ProductID, err)
 			}
 			
-			// Publica evento de estoque reservado
-			stockEvent := pkgevents.StockReserved{
+			log.Error().
+				Err(err).
+				Uint("product_id", item.ProductID).
+				Int("quantity", item.Quantity).
+				Msg("erro ao reservar estoque")
+			
+			// Publica evento de cancelamento
+			cancelEvent := pkgevents.OrderCanceled{
 				BaseEvent: pkgevents.NewBaseEvent(),
 				OrderID:   event.Order.ID,
-				ProductID: item.ProductID,
-				Quantity:  item.Quantity,
+				Reason:    fmt.Sprintf("Estoque insuficiente para produto %d", item.ProductID),
 			}
 			
-			if err := c.kafkaProducer.PublishEvent(ctx, "stock.reserved", stockEvent); err != nil {
-				log.Error().Err(err).Msg("erro ao publicar evento de estoque reservado")
-				return err
+			if err := c.kafkaProducer.PublishEventWithKey(ctx, "order.canceled", orderKey, cancelEvent); err != nil {
+				log.Error().Err(err).Msg("erro ao publicar evento de cancelamento")
+				// Sem o cancelamento o pedido ficaria pendente: o evento é reprocessado pelo retry
+				return fmt.Errorf("erro ao publicar cancelamento do pedido %d: %w", event.Order.ID, err)
 			}
 			
-			log.Info().
-				Uint("product_id", item.ProductID).
-				Int("quantity", item.Quantity).
-				Msg("estoque reservado com sucesso")
+			// Sem estoque ou produto inexistente: o cancelamento publicado conclui o evento
+			return nil
+		}
+		
+		// Publica evento de estoque reservado
+		stockEvent := pkgevents.StockReserved{
+			BaseEvent: pkgevents.NewBaseEvent(),
+			OrderID:   event.Order.ID,
+			ProductID: item.ProductID,
+			Quantity:  item.Quantity,
 		}
 		
-		return nil
-	})
+		if err := c.kafkaProducer.PublishEventWithKey(ctx, "stock.reserved", orderKey, stockEvent); err != nil {
+			log.Error().Err(err).Msg("erro ao publicar evento de estoque reservado")
+			return err
+		}
+		
+		log.Info().
+			Uint("product_id", item.ProductID).
+			Int("quantity", item.Quantity).
+			Msg("estoque reservado com sucesso")
+	}
+	
+	return nil
 }
 
 // HandleOrderCanceled processa evento de pedido cancelado
 func (c *OrderConsumer) HandleOrderCanceled(ctx context.Context, message []byte) error {
 	var event pkgevents.OrderCanceled
 	if err := json.Unmarshal(message, &event); err != nil {
-		return fmt.Errorf("erro ao deserializar evento: %w", err)
+		return pkgkafka.Permanent(fmt.Errorf("erro ao deserializar evento: %w", err))
 	}
 	
-	return c.idempotencyHandler.ProcessWithIdempotency(ctx, event.EventID, func() error {
-		log.Info().
-			Str("event_id", event.EventID).
-			Uint("order_id", event.OrderID).
-			Msg("processando evento order.canceled")
-		
-		// Nota: Em um cenário real, você precisaria buscar os itens do pedido
-		// para liberar o estoque. Por simplicidade, vamos apenas logar o evento.
-		// Em uma implementação completa, você faria uma chamada para o order-service
-		// ou teria acesso aos dados do pedido através de uma projeção.
-		
-		return nil
-	})
+	log.Info().
+		Str("event_id", event.EventID).
+		Uint("order_id", event.OrderID).
+		Msg("processando evento order.canceled")
+	
+	// Nota: Em um cenário real, você precisaria buscar os itens do pedido
+	// para liberar o estoque. Por simplicidade, vamos apenas logar o evento.
+	// Em uma implementação completa, você faria uma chamada para o order-service
+	// ou teria acesso aos dados do pedido através de uma projeção.
+	
+	return nil
 }
@@ -1,10 +1,14 @@
 package entities
 
 import (
+	"errors"
 	"fmt"
 	"time"
 )
 
+// ErrInsufficientStock estoque do produto menor que a quantidade solicitada
+var ErrInsufficientStock = errors.New("estoque insuficiente")
+
 // Product representa a entidade produto
 type Product struct {
 	ID        uint      `json:"id" gorm:"primaryKey"`
@@ -17,7 +21,7 @@ type Product struct {
 // ReserveStock reserva estoque do produto
 func (p *Product) ReserveStock(quantity int) error {
 	if p.Stock < quantity {
-		return fmt.Errorf("estoque insuficiente: disponível %d, solicitado %d", p.Stock, quantity)
+		return fmt.Errorf("%w: disponível %d, solicitado %d", ErrInsufficientStock, p.Stock, quantity)
 	}
 	p.Stock -= quantity
 	return nil
@@ -8,6 +8,7 @@ import (
 	"query-api/internal/repo"
 	"query-api/internal/services"
 	pkgconfig "pkg/config"
+	pkglifecycle "pkg/lifecycle"
 	pkglog "pkg/log"
 	pkghttp "pkg/http"
 
@@ -32,12 +33,15 @@ func main() {
 		Int("port", config.Port).
 		Msg("iniciando query-api")
 	
+	// Ciclo de vida: desligamento gracioso em SIGINT/SIGTERM
+	app := pkglifecycle.New(config.GetShutdownTimeout())
+	
 	// Conecta ao MongoDB
 	client, err := mongo.Connect(context.Background(), options.Client().ApplyURI(config.MongoURI))
 	if err != nil {
 		log.Fatal().Err(err).Msg("erro ao conectar ao MongoDB")
 	}
-	defer client.Disconnect(context.Background())
+	app.OnClose("mongo", pkglifecycle.CloseMongo(client))
 	
 	// Verifica conexão
 	if err := client.Ping(context.Background(), nil); err != nil {
@@ -73,9 +77,9 @@ func main() {
 	// Configura rotas
 	routes.SetupQueryRoutes(router, userController, productController, orderController)
 	
-	// Inicia servidor
-	log.Info().Msg("servidor iniciado")
-	if err := router.Run(fmt.Sprintf(":%d", config.Port)); err != nil {
-		log.Fatal().Err(err).Msg("erro ao iniciar servidor")
+	// Inicia servidor e aguarda SIGINT/SIGTERM
+	app.Serve("http", fmt.Sprintf(":%d", config.Port), router)
+	if err := app.Wait(); err != nil {
+		log.Fatal().Err(err).Msg("erro no desligamento do servidor")
 	}
 }
@@ -2,14 +2,20 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"query-consumer/internal/consumer"
 	"query-consumer/internal/repository"
 	"query-consumer/internal/services"
+	pkgbroker "pkg/broker"
 	pkgconfig "pkg/config"
+	pkghttp "pkg/http"
 	pkgkafka "pkg/kafka"
+	pkgkafkaadmin "pkg/kafka/admin"
+	pkglifecycle "pkg/lifecycle"
 	pkglog "pkg/log"
 	pkgidempotency "pkg/idempotency"
 
+	"github.com/gin-gonic/gin"
 	"github.com/rs/zerolog/log"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
@@ -29,12 +35,15 @@ func main() {
 		Str("service", config.ServiceName).
 		Msg("iniciando query-consumer")
 	
+	// Ciclo de vida: desligamento gracioso em SIGINT/SIGTERM
+	app := pkglifecycle.New(config.GetShutdownTimeout())
+	
 	// Conecta ao MongoDB
 	client, err := mongo.Connect(context.Background(), options.Client().ApplyURI(config.MongoURI))
 	if err != nil {
 		log.Fatal().Err(err).Msg("erro ao conectar ao MongoDB")
 	}
-	defer client.Disconnect(context.Background())
+	app.OnClose("mongo", pkglifecycle.CloseMongo(client))
 	
 	// Verifica conexão
 	if err := client.Ping(context.Background(), nil); err != nil {
@@ -53,9 +62,16 @@ func main() {
 	userService := services.NewUserService(userRepository)
 	productService := services.NewProductService(productRepository)
 	
-	// Inicializa Kafka producer
-	kafkaProducer := pkgkafka.NewProducer(config.GetKafkaBrokers())
-	defer kafkaProducer.Close()
+	// Transporte de mensagens (BROKER_BACKEND: kafka, nats ou memory)
+	messageBroker, err := pkgbroker.New(config)
+	if err != nil {
+		log.Fatal().Err(err).Msg("erro ao inicializar o broker de mensagens")
+	}
+	app.OnClose("broker", pkglifecycle.Closer(messageBroker))
+	
+	// Inicializa producer
+	kafkaProducer := pkgkafka.NewProducerWithBroker(messageBroker)
+	app.OnClose("kafka-producer", pkglifecycle.Closer(kafkaProducer))
 	
 	// Inicializa idempotência usando MongoDB
 	idempotencyRepo := pkgidempotency.NewMongoRepository(db)
@@ -69,71 +85,71 @@ func main() {
 		userRepository,
 		productRepository,
 		kafkaProducer,
-		idempotencyHandler,
 	)
 	
-	// Inicia consumidores em background
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
-	
-	// Consumidor de user.created
-	go func() {
-		consumer := pkgkafka.NewConsumer(config.GetKafkaBrokers(), "user.created", "query-consumer", kafkaProducer)
-		defer consumer.Close()
-		consumer.Consume(ctx, eventConsumer.HandleUserCreated)
-	}()
-	
-	// Consumidor de product.created
-	go func() {
-		consumer := pkgkafka.NewConsumer(config.GetKafkaBrokers(), "product.created", "query-consumer", kafkaProducer)
-		defer consumer.Close()
-		consumer.Consume(ctx, eventConsumer.HandleProductCreated)
-	}()
-	
-	// Consumidor de product.updated
-	go func() {
-		consumer := pkgkafka.NewConsumer(config.GetKafkaBrokers(), "product.updated", "query-consumer", kafkaProducer)
-		defer consumer.Close()
-		consumer.Consume(ctx, eventConsumer.HandleProductUpdated)
-	}()
-	
-	// Consumidor de order.created
-	go func() {
-		consumer := pkgkafka.NewConsumer(config.GetKafkaBrokers(), "order.created", "query-consumer", kafkaProducer)
-		defer consumer.Close()
-		consumer.Consume(ctx, eventConsumer.HandleOrderCreated)
-	}()
-	
-	// Consumidor de order.paid
-	go func() {
-		consumer := pkgkafka.NewConsumer(config.GetKafkaBrokers(), "order.paid", "query-consumer", kafkaProducer)
-		defer consumer.Close()
-		consumer.Consume(ctx, eventConsumer.HandleOrderPaid)
-	}()
-	
-	// Consumidor de order.canceled
-	go func() {
-		consumer := pkgkafka.NewConsumer(config.GetKafkaBrokers(), "order.canceled", "query-consumer", kafkaProducer)
-		defer consumer.Close()
-		consumer.Consume(ctx, eventConsumer.HandleOrderCanceled)
-	}()
-	
-	// Consumidor de stock.reserved
-	go func() {
-		consumer := pkgkafka.NewConsumer(config.GetKafkaBrokers(), "stock.reserved", "query-consumer", kafkaProducer)
-		defer consumer.Close()
-		consumer.Consume(ctx, eventConsumer.HandleStockReserved)
-	}()
-	
-	// Consumidor de stock.released
-	go func() {
-		consumer := pkgkafka.NewConsumer(config.GetKafkaBrokers(), "stock.released", "query-consumer", kafkaProducer)
-		defer consumer.Close()
-		consumer.Consume(ctx, eventConsumer.HandleStockReleased)
-	}()
+	// Tópicos de retry dos consumidores (<tópico>.<grupo>.retry.<atraso>)
+	retryDelays, err := pkgkafka.ParseRetryDelays(config.KafkaRetryDelays)
+	if err != nil {
+		log.Fatal().Err(err).Msg("erro ao interpretar KAFKA_RETRY_DELAYS")
+	}
+	
+	// Circuit breaker: com a dependência fora do ar, o consumo é suspenso (sem commit) em vez de
+	// esgotar os retries; também permite pausa manual pela API administrativa
+	consumerBreaker := pkgkafka.NewCircuitBreaker(pkgkafka.CircuitBreakerConfig{
+		FailureThreshold: config.KafkaCircuitFailureThreshold,
+		OpenTimeout:      config.GetKafkaCircuitOpenTimeout(),
+		MaxProbes:        config.KafkaCircuitMaxProbes,
+	})
+	
+	// Um único consumer group lê todos os tópicos; cada tópico é roteado para seu handler
+	kafkaRouter := pkgkafka.NewRouterWithBroker(messageBroker, "query-consumer", kafkaProducer)
+	kafkaRouter.SetRetryDelays(retryDelays)
+	kafkaRouter.SetWorkers(config.KafkaConsumerWorkers)
+	kafkaRouter.SetCircuitBreaker(consumerBreaker)
+	// Middlewares aplicados a todos os handlers: rastreamento, log, métricas, recuperação de
+	// panic, timeout e idempotência pelo event_id
+	kafkaRouter.Use(
+		pkgkafka.Tracing(),
+		pkgkafka.Logging(),
+		pkgkafka.Metrics(),
+		pkgkafka.Recovery(),
+		pkgkafka.Timeout(config.GetKafkaHandlerTimeout()),
+		pkgkafka.Idempotency(idempotencyHandler),
+	)
+	kafkaRouter.Handle("user.created", eventConsumer.HandleUserCreated)
+	kafkaRouter.Handle("product.created", eventConsumer.HandleProductCreated)
+	kafkaRouter.Handle("product.updated", eventConsumer.HandleProductUpdated)
+	kafkaRouter.Handle("order.created", eventConsumer.HandleOrderCreated)
+	kafkaRouter.Handle("order.paid", eventConsumer.HandleOrderPaid)
+	kafkaRouter.Handle("order.canceled", eventConsumer.HandleOrderCanceled)
+	kafkaRouter.Handle("stock.reserved", eventConsumer.HandleStockReserved)
+	kafkaRouter.Handle("stock.released", eventConsumer.HandleStockReleased)
+	
+	// Tópicos declarados em pkg/kafka e os lidos pelo Router, com o retry de cada rota: cria os
+	// ausentes e interrompe a inicialização se algum divergir (corrija com make migrate-topics)
+	if err := pkgbroker.EnsureTopics(app.Context(), config, kafkaRouter); err != nil {
+		log.Fatal().Err(err).Msg("erro ao provisionar os tópicos do Kafka")
+	}
+	
+	// Inicia consumo em background; no desligamento, a mensagem em processamento é concluída
+	app.Go("kafka-router", kafkaRouter.Run)
+	
+	// Servidor HTTP administrativo (healthcheck e consumo)
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	router.Use(pkghttp.Logger())
+	router.Use(pkghttp.Recovery())
+	router.GET("/healthz", pkghttp.HealthCheck())
+	router.GET("/metrics", pkghttp.Metrics())
+	admin := router.Group("", pkghttp.AdminAuth(config.AdminToken))
+	pkgkafkaadmin.SetupRoutes(admin, pkgkafkaadmin.NewConsumerAdminController(consumerBreaker))
+	
+	app.Serve("admin", fmt.Sprintf(":%d", config.Port), router)
 	
 	log.Info().Msg("query-consumer iniciado")
 	
-	// Aguarda indefinidamente
-	select {}
+	// Aguarda SIGINT/SIGTERM
+	if err := app.Wait(); err != nil {
+		log.Fatal().Err(err).Msg("erro no desligamento do consumer")
+	}
 }
@@ -3,6 +3,7 @@ package services
 import (
 	"context"
 	"fmt"
+	"strconv"
 	"user-consumer/internal/domain/entities"
 	"user-consumer/internal/repo"
 	pkgoutboxservices "pkg/outbox/services"
@@ -29,7 +30,7 @@ func NewUserService(userRepo repo.UserRepository, outboxService pkgoutboxservice
 
 // CreateUserWithEvent cria um usuário e grava o evento na outbox na mesma transação
 func (s *UserService) CreateUserWithEvent(ctx context.Context, user *entities.User) error {
-	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+	return s.outboxService.RunInTransaction(ctx, s.db, func(tx *gorm.DB) error {
 		// Verifica se email já existe usando o repositório
 		existingUser, err := s.userRepo.GetByEmail(ctx, user.Email)
 		if err == nil && existingUser != nil {
@@ -52,7 +53,9 @@ func (s *UserService) CreateUserWithEvent(ctx context.Context, user *entities.Us
 		}
 		
 		// Cria a mensagem da outbox usando o serviço dentro da transação
-		_, err = s.outboxService.CreateMessageInTransaction(ctx, tx, "user", "user.created", event)
+		_, err = s.outboxService.CreateMessageInTransaction(ctx, tx, "user", "user.created", event, pkgevents.Metadata{
+			AggregateID: strconv.FormatUint(uint64(user.ID), 10),
+		})
 		if err != nil {
 			return err
 		}